@@ -0,0 +1,312 @@
+package rest
+
+import (
+    "crypto/subtle"
+    "fmt"
+    "github.com/maxmanuylov/go-rest/error"
+    "net/http"
+    "strings"
+)
+
+type Operation string
+
+const (
+    OperationList        Operation = "list"
+    OperationRead        Operation = "read"
+    OperationCreate      Operation = "create"
+    OperationUpdate      Operation = "update"
+    OperationReplace     Operation = "replace"
+    OperationDelete      Operation = "delete"
+    OperationBatchDelete Operation = "batch-delete"
+
+    actionOperationPrefix = "action:"
+)
+
+var (
+    ErrUnauthorized = rest_error.NewByCode(http.StatusUnauthorized)
+    ErrForbidden    = rest_error.NewByCode(http.StatusForbidden)
+)
+
+func ActionOperation(method string) Operation {
+    return Operation(actionOperationPrefix + strings.ToUpper(method))
+}
+
+// Authenticator returns a nil principal without an error for anonymous requests;
+// an error means the supplied credentials are invalid and results in 401.
+type Authenticator interface {
+    Authenticate(request *Request) (interface{}, error)
+}
+
+type AuthenticatorFunc func(request *Request) (interface{}, error)
+
+func (f AuthenticatorFunc) Authenticate(request *Request) (interface{}, error) {
+    return f(request)
+}
+
+// Challenger is an optional Authenticator extension providing the WWW-Authenticate header value.
+type Challenger interface {
+    Challenge() string
+}
+
+// Authorizer denies an operation by returning an error: plain errors become 401 for anonymous requests
+// and 403 otherwise, rest_error.Error values are sent as is.
+type Authorizer interface {
+    Authorize(request *Request, operation Operation) error
+}
+
+type AuthorizerFunc func(request *Request, operation Operation) error
+
+func (f AuthorizerFunc) Authorize(request *Request, operation Operation) error {
+    return f(request, operation)
+}
+
+var Authenticated Authorizer = AuthorizerFunc(func(request *Request, _ Operation) error {
+    if request.Principal() == nil {
+        return ErrUnauthorized
+    }
+    return nil
+})
+
+func (server *Server) Authenticator(authenticator Authenticator) *Server {
+    server.authenticator = authenticator
+    return server
+}
+
+// Authorizer applies to every operation on the collection and its sub-collections;
+// ancestors' authorizers run first, operation-specific ones of ResourceCollection last.
+func (collection *Collection) Authorizer(authorizer Authorizer) *Collection {
+    collection.authorizer = authorizer
+    return collection
+}
+
+func (collection *ResourceCollection) Authorize(operation Operation, authorizer Authorizer) *ResourceCollection {
+    collection.resourceHandler.authorizers[operation] = authorizer
+    return collection
+}
+
+func (collection *ResourceCollection) AuthorizeAction(method string, authorizer Authorizer) *ResourceCollection {
+    return collection.Authorize(ActionOperation(method), authorizer)
+}
+
+func (r *Request) Principal() interface{} {
+    return r.principal
+}
+
+func (server *Server) effectiveAuthenticator() Authenticator {
+    for current := server; current != nil; current = current.parent {
+        if current.authenticator != nil {
+            return current.authenticator
+        }
+    }
+    return nil
+}
+
+func (server *Server) authenticating(handler Handler) Handler {
+    return HandlerFunc(func(request *Request, response http.ResponseWriter) {
+        if server.authenticate(request, response) {
+            handler.ServeHTTP(request, response)
+        }
+    })
+}
+
+func (server *Server) authenticate(request *Request, response http.ResponseWriter) bool {
+    authenticator := server.effectiveAuthenticator()
+    if authenticator == nil {
+        return true
+    }
+
+    principal, err := authenticator.Authenticate(request)
+    if err != nil {
+        writeAuthError(request, response, authenticator, denial(nil, err))
+        return false
+    }
+
+    request.principal = principal
+
+    return true
+}
+
+func (collection *Collection) authorizing(handler Handler) Handler {
+    return HandlerFunc(func(request *Request, response http.ResponseWriter) {
+        operation := requestOperation(request)
+        if operation == "" {
+            handler.ServeHTTP(request, response)
+            return
+        }
+
+        authorizers := make([]Authorizer, 0)
+        for current := collection; current != nil; current = current.parent {
+            authorizers = append([]Authorizer{current.authorizer}, authorizers...)
+        }
+        if adapter, ok := collection.handler.(*resourceHandlerAdapter); ok {
+            authorizers = append(authorizers, adapter.authorizers[operation])
+        }
+
+        for _, authorizer := range authorizers {
+            if authorizer == nil {
+                continue
+            }
+            if err := authorizer.Authorize(request, operation); err != nil {
+                writeAuthError(request, response, collection.server.effectiveAuthenticator(), denial(request.principal, err))
+                return
+            }
+        }
+
+        handler.ServeHTTP(request, response)
+    })
+}
+
+func requestOperation(request *Request) Operation {
+    collectionRequest := request.Level == len(request.IDs)
+
+    switch method := strings.ToUpper(request.Method); method {
+    case "GET", "HEAD":
+        if collectionRequest {
+            return OperationList
+        }
+        return OperationRead
+    case "POST":
+        if collectionRequest {
+            return OperationCreate
+        }
+        return OperationUpdate
+    case "PUT":
+        return OperationReplace
+    case "PATCH":
+        return OperationUpdate
+    case "DELETE":
+        if collectionRequest {
+            return OperationBatchDelete
+        }
+        return OperationDelete
+    case "OPTIONS":
+        return ""
+    default:
+        return ActionOperation(method)
+    }
+}
+
+func denial(principal interface{}, err error) *rest_error.Error {
+    if restError, ok := err.(*rest_error.Error); ok {
+        return restError
+    }
+    if principal == nil {
+        return rest_error.New(http.StatusUnauthorized, err.Error())
+    }
+    return rest_error.New(http.StatusForbidden, err.Error())
+}
+
+func writeAuthError(request *Request, response http.ResponseWriter, authenticator Authenticator, err *rest_error.Error) {
+    if challenger, ok := authenticator.(Challenger); ok && err.Code == http.StatusUnauthorized {
+        response.Header().Set("WWW-Authenticate", challenger.Challenge())
+    }
+    writeError(response, request.Request, err)
+}
+
+// BearerTokenAuthenticator treats requests without a bearer token as anonymous;
+// Verify returning a nil principal rejects the token.
+type BearerTokenAuthenticator struct {
+    Realm  string
+    Verify func(request *Request, token string) (interface{}, error)
+}
+
+func (authenticator *BearerTokenAuthenticator) Authenticate(request *Request) (interface{}, error) {
+    authorization := request.Header.Get("Authorization")
+    if authorization == "" {
+        return nil, nil
+    }
+
+    scheme, token := splitAuthorization(authorization)
+    if !strings.EqualFold(scheme, "Bearer") || token == "" {
+        return nil, rest_error.New(http.StatusUnauthorized, "Bearer token is expected")
+    }
+
+    return verified(authenticator.Verify(request, token))
+}
+
+func (authenticator *BearerTokenAuthenticator) Challenge() string {
+    return challenge("Bearer", authenticator.Realm)
+}
+
+// BasicAuthenticator treats requests without credentials as anonymous;
+// Verify returning a nil principal rejects the credentials.
+type BasicAuthenticator struct {
+    Realm  string
+    Verify func(request *Request, username, password string) (interface{}, error)
+}
+
+func (authenticator *BasicAuthenticator) Authenticate(request *Request) (interface{}, error) {
+    if request.Header.Get("Authorization") == "" {
+        return nil, nil
+    }
+
+    username, password, ok := request.BasicAuth()
+    if !ok {
+        return nil, rest_error.New(http.StatusUnauthorized, "Basic credentials are expected")
+    }
+
+    return verified(authenticator.Verify(request, username, password))
+}
+
+func (authenticator *BasicAuthenticator) Challenge() string {
+    return challenge("Basic", authenticator.Realm)
+}
+
+// StaticTokens accepts the given tokens only, mapping each one to its principal; intended for tests.
+func StaticTokens(tokens map[string]interface{}) *BearerTokenAuthenticator {
+    return &BearerTokenAuthenticator{
+        Verify: func(_ *Request, token string) (interface{}, error) {
+            for knownToken, principal := range tokens {
+                if subtle.ConstantTimeCompare([]byte(knownToken), []byte(token)) == 1 {
+                    return principal, nil
+                }
+            }
+            return nil, nil
+        },
+    }
+}
+
+// StaticCredentials accepts the given username/password pairs only, using the username as the principal; intended for tests.
+func StaticCredentials(passwords map[string]string) *BasicAuthenticator {
+    return &BasicAuthenticator{
+        Verify: func(_ *Request, username, password string) (interface{}, error) {
+            knownPassword, ok := passwords[username]
+            if ok && subtle.ConstantTimeCompare([]byte(knownPassword), []byte(password)) == 1 {
+                return username, nil
+            }
+            return nil, nil
+        },
+    }
+}
+
+// FixedPrincipal authenticates every request as the given principal; intended for tests.
+func FixedPrincipal(principal interface{}) Authenticator {
+    return AuthenticatorFunc(func(*Request) (interface{}, error) {
+        return principal, nil
+    })
+}
+
+func verified(principal interface{}, err error) (interface{}, error) {
+    if err != nil {
+        return nil, err
+    }
+    if principal == nil {
+        return nil, rest_error.New(http.StatusUnauthorized, "Invalid credentials")
+    }
+    return principal, nil
+}
+
+func splitAuthorization(authorization string) (string, string) {
+    parts := strings.SplitN(strings.TrimSpace(authorization), " ", 2)
+    if len(parts) != 2 {
+        return parts[0], ""
+    }
+    return parts[0], strings.TrimSpace(parts[1])
+}
+
+func challenge(scheme, realm string) string {
+    if realm == "" {
+        return scheme
+    }
+    return fmt.Sprintf("%s realm=%q", scheme, realm)
+}
@@ -0,0 +1,209 @@
+package rest
+
+import (
+    "encoding/json"
+    "fmt"
+    "github.com/maxmanuylov/go-rest/error"
+    "net/http"
+)
+
+type BatchMode string
+
+const (
+    BestEffort   BatchMode = "best-effort"
+    AllOrNothing BatchMode = "all-or-nothing"
+
+    BatchModeParam = "mode"
+    BatchFlag      = "batch"
+)
+
+type BatchResult struct {
+    ID  string
+    Err error
+}
+
+type BatchUpdateItem struct {
+    ID   string
+    Item interface{}
+}
+
+// BatchCreator handles POST requests with the batch flag set, e.g. POST /users?batch, carrying a list of items.
+// In the AllOrNothing mode the adapter only rejects the whole batch when some item is invalid; CreateBatch
+// is responsible for rolling back its own partial writes if a later item fails to be stored.
+type BatchCreator interface {
+    CreateBatch(request *Request, items []interface{}, mode BatchMode) ([]*BatchResult, error)
+}
+
+// BatchUpdater handles PATCH requests to the collection itself, the AllOrNothing caveat of BatchCreator applies.
+type BatchUpdater interface {
+    UpdateBatch(request *Request, items []*BatchUpdateItem, mode BatchMode) ([]*BatchResult, error)
+}
+
+type BatchItemStatus struct {
+    Index    int               `json:"index"`
+    Status   int               `json:"status"`
+    ID       string            `json:"id,omitempty"`
+    Location string            `json:"location,omitempty"`
+    Error    *rest_error.Error `json:"error,omitempty"`
+}
+
+type batchUpdateEntry struct {
+    ID   string          `json:"id"`
+    Item json.RawMessage `json:"item"`
+}
+
+func (resourceHandler *resourceHandlerAdapter) handleBatchCreate(request *Request, response http.ResponseWriter, batchCreator BatchCreator, elements []json.RawMessage) {
+    mode, err := batchMode(request)
+    if err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
+    items := make([]interface{}, len(elements))
+    errs := make([]error, len(elements))
+
+    for i, element := range elements {
+        items[i], errs[i] = resourceHandler.convertItem(request, element, Create)
+    }
+
+    resourceHandler.doBatch(request, response, mode, errs, http.StatusCreated, func(indices []int) ([]*BatchResult, error) {
+        batchItems := make([]interface{}, len(indices))
+        for i, index := range indices {
+            batchItems[i] = items[index]
+        }
+        return batchCreator.CreateBatch(request, batchItems, mode)
+    })
+}
+
+func (resourceHandler *resourceHandlerAdapter) handleBatchUpdate(request *Request, response http.ResponseWriter, batchUpdater BatchUpdater) {
+    mode, err := batchMode(request)
+    if err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
+    itemsContent, err := request.ReadBody()
+    if err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
+    entries := make([]*batchUpdateEntry, 0)
+    if err := request.Unmarshal(itemsContent, &entries); err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
+    items := make([]*BatchUpdateItem, len(entries))
+    errs := make([]error, len(entries))
+
+    for i, entry := range entries {
+        if entry == nil || entry.ID == "" {
+            errs[i] = rest_error.NewWithReason(http.StatusBadRequest, rest_error.ReasonValidation, "Item id is not specified")
+            continue
+        }
+        item, err := resourceHandler.convertItem(request, entry.Item, Update)
+        items[i], errs[i] = &BatchUpdateItem{ID: entry.ID, Item: item}, err
+    }
+
+    resourceHandler.doBatch(request, response, mode, errs, http.StatusOK, func(indices []int) ([]*BatchResult, error) {
+        batchItems := make([]*BatchUpdateItem, len(indices))
+        for i, index := range indices {
+            batchItems[i] = items[index]
+        }
+        results, err := batchUpdater.UpdateBatch(request, batchItems, mode)
+        for i, result := range results {
+            if i < len(batchItems) {
+                if result == nil {
+                    results[i] = &BatchResult{ID: batchItems[i].ID}
+                } else if result.ID == "" {
+                    result.ID = batchItems[i].ID
+                }
+            }
+        }
+        return results, err
+    })
+}
+
+func (resourceHandler *resourceHandlerAdapter) doBatch(request *Request, response http.ResponseWriter, mode BatchMode, errs []error, successStatus int, process func([]int) ([]*BatchResult, error)) {
+    statuses := make([]*BatchItemStatus, len(errs))
+    valid := make([]int, 0, len(errs))
+
+    for i, err := range errs {
+        statuses[i] = &BatchItemStatus{Index: i}
+        if err != nil {
+            statuses[i].setError(err)
+        } else {
+            valid = append(valid, i)
+        }
+    }
+
+    if mode == AllOrNothing && len(valid) != len(errs) {
+        for _, index := range valid {
+            statuses[index].setError(rest_error.New(http.StatusFailedDependency, "Batch is rejected because of other invalid items"))
+        }
+    } else if len(valid) != 0 {
+        results, err := process(valid)
+        if err != nil {
+            writeError(response, request.Request, err)
+            return
+        }
+
+        if len(results) != len(valid) {
+            writeError(response, request.Request, fmt.Errorf("Batch handler returned %d results for %d items", len(results), len(valid)))
+            return
+        }
+
+        for i, index := range valid {
+            if result := results[i]; result == nil {
+                statuses[index].Status = successStatus
+            } else if result.Err != nil {
+                statuses[index].setError(result.Err)
+            } else {
+                statuses[index].Status = successStatus
+                if result.ID != "" {
+                    statuses[index].ID = result.ID
+                    if successStatus == http.StatusCreated {
+                        statuses[index].Location = itemLocation(request, result.ID)
+                    }
+                }
+            }
+        }
+    }
+
+    writeValue(request, response, http.StatusMultiStatus, statuses)
+}
+
+func (status *BatchItemStatus) setError(err error) {
+    status.Error = rest_error.From(err)
+    status.Status = status.Error.Code
+}
+
+func (resourceHandler *resourceHandlerAdapter) convertItem(request *Request, element json.RawMessage, action ItemAction) (interface{}, error) {
+    if len(element) == 0 {
+        element = json.RawMessage("null")
+    }
+
+    item := resourceHandler.resourceHandler.EmptyItem()
+
+    if err := decodeJson(request, element, item); err != nil {
+        return nil, err
+    }
+
+    if err := CheckRestrictions(item, action); err != nil {
+        return nil, rest_error.From(err)
+    }
+
+    return item, nil
+}
+
+func batchMode(request *Request) (BatchMode, error) {
+    switch mode := BatchMode(request.GetParam(BatchModeParam)); mode {
+    case "", BestEffort:
+        return BestEffort, nil
+    case AllOrNothing:
+        return AllOrNothing, nil
+    default:
+        return "", invalidParameter(BatchModeParam, fmt.Sprintf("Unknown batch mode: %s", mode))
+    }
+}
@@ -0,0 +1,78 @@
+package rest
+
+import (
+    "github.com/maxmanuylov/go-rest/error"
+    "net/http"
+    "net/url"
+    "strings"
+)
+
+const (
+    IDParam       = "id"
+    IDsParam      = "ids"
+    DeleteAllFlag = "all"
+
+    ReasonSelectorRequired     = "selector_required"
+    ReasonSelectorNotSupported = "selector_not_supported"
+)
+
+type DeleteSelector struct {
+    IDs     []string
+    Filters map[string][]string
+    All     bool
+}
+
+type DeleteResult struct {
+    Deleted int `json:"deleted"`
+}
+
+// SelectiveBatchDeleter handles DELETE requests to the collection and reports the number of deleted items.
+// Without it the collection can only be deleted as a whole with ?all: BatchDelete is called and the response
+// is 204 No Content, as there is no count to report.
+type SelectiveBatchDeleter interface {
+    DeleteWhere(request *Request, selector *DeleteSelector) (int, error)
+}
+
+func (r *Request) DeleteSelector() *DeleteSelector {
+    if r.deleteSelector == nil {
+        return &DeleteSelector{}
+    }
+    return r.deleteSelector
+}
+
+func parseDeleteSelector(query url.Values, policy *ListPolicy) (*DeleteSelector, error) {
+    selector := &DeleteSelector{
+        IDs: make([]string, 0),
+    }
+
+    for _, id := range query[IDParam] {
+        if id = strings.TrimSpace(id); id != "" {
+            selector.IDs = append(selector.IDs, id)
+        }
+    }
+
+    for _, ids := range query[IDsParam] {
+        for _, id := range strings.Split(ids, ",") {
+            if id = strings.TrimSpace(id); id != "" {
+                selector.IDs = append(selector.IDs, id)
+            }
+        }
+    }
+
+    filters, err := parseFilters(query, policy)
+    if err != nil {
+        return nil, err
+    }
+
+    selector.Filters = filters
+
+    if values, ok := query[DeleteAllFlag]; ok && len(values) != 0 {
+        selector.All = parseFlag(values[0])
+    }
+
+    if len(selector.IDs) == 0 && len(selector.Filters) == 0 && !selector.All {
+        return nil, rest_error.NewWithReason(http.StatusBadRequest, ReasonSelectorRequired, "Batch delete requires ids, filters or the all flag")
+    }
+
+    return selector, nil
+}
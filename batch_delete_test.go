@@ -0,0 +1,83 @@
+package rest
+
+import (
+    "net/http"
+    "testing"
+)
+
+type batchDeleteItems struct {
+    deleted bool
+}
+
+func (h *batchDeleteItems) EmptyItem() interface{}                       { return &struct{}{} }
+func (h *batchDeleteItems) Create(*Request, interface{}) (string, error) { return "1", nil }
+func (h *batchDeleteItems) Read(*Request) (interface{}, error)           { return nil, nil }
+func (h *batchDeleteItems) List(*Request) (interface{}, error)           { return nil, nil }
+func (h *batchDeleteItems) Update(*Request, interface{}) error           { return nil }
+func (h *batchDeleteItems) Replace(*Request, interface{}) error          { return nil }
+func (h *batchDeleteItems) Delete(*Request) error                        { return nil }
+
+func (h *batchDeleteItems) BatchDelete(*Request) error {
+    h.deleted = true
+    return nil
+}
+
+func TestBatchDeleteFallback(t *testing.T) {
+    tests := []struct {
+        path    string
+        status  int
+        deleted bool
+    }{
+        {path: "/items?all", status: http.StatusNoContent, deleted: true},
+        {path: "/items?all=true", status: http.StatusNoContent, deleted: true},
+        {path: "/items", status: http.StatusBadRequest},
+        {path: "/items?all=false", status: http.StatusBadRequest},
+        {path: "/items?ids=1,2", status: http.StatusNotImplemented},
+        {path: "/items?all&id=1", status: http.StatusNotImplemented},
+    }
+
+    for _, test := range tests {
+        handler := &batchDeleteItems{}
+
+        server := NewServer()
+        server.Collection("items").Handler(handler)
+
+        response := serve(server, "DELETE", test.path)
+        if response.Code != test.status {
+            t.Errorf("%s: expected status %d, got %d", test.path, test.status, response.Code)
+        }
+        if handler.deleted != test.deleted {
+            t.Errorf("%s: expected BatchDelete called %v, got %v", test.path, test.deleted, handler.deleted)
+        }
+        if test.status == http.StatusNoContent && response.Body.Len() != 0 {
+            t.Errorf("%s: unexpected body %q", test.path, response.Body.String())
+        }
+    }
+}
+
+type selectiveDeleteItems struct {
+    batchDeleteItems
+
+    selector *DeleteSelector
+}
+
+func (h *selectiveDeleteItems) DeleteWhere(request *Request, selector *DeleteSelector) (int, error) {
+    h.selector = selector
+    return len(selector.IDs), nil
+}
+
+func TestSelectiveBatchDelete(t *testing.T) {
+    handler := &selectiveDeleteItems{}
+
+    server := NewServer()
+    server.Collection("items").Handler(handler)
+
+    response := serve(server, "DELETE", "/items?ids=1,2&id=3")
+    if response.Code != http.StatusOK || response.Body.String() != `{"deleted":3}` {
+        t.Errorf("unexpected response %d %s", response.Code, response.Body.String())
+    }
+    if handler.deleted {
+        t.Error("BatchDelete must not be called for a selective deleter")
+    }
+}
+
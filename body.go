@@ -0,0 +1,206 @@
+package rest
+
+import (
+    "bytes"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "github.com/ghodss/yaml"
+    "github.com/maxmanuylov/go-rest/error"
+    yamlv2 "gopkg.in/yaml.v2"
+    "io"
+    "io/ioutil"
+    "net/http"
+)
+
+// Request bodies are not limited unless MaxBodySize is configured on the server or a collection;
+// DefaultMaxBodySize suits typical JSON APIs, NoBodyLimit lifts a limit inherited from the parents.
+const (
+    DefaultMaxBodySize int64 = 10 << 20
+    NoBodyLimit        int64 = -1
+)
+
+var errTrailingData = errors.New("Unexpected data after the top-level value")
+
+// StreamCodec is an optional Codec extension decoding values directly from a reader; trailing data
+// after the value is always rejected, strict mode also rejects unknown fields.
+type StreamCodec interface {
+    Decode(reader io.Reader, v interface{}, strict bool) error
+}
+
+func (JsonCodec) Decode(reader io.Reader, v interface{}, strict bool) error {
+    decoder := json.NewDecoder(reader)
+    if strict {
+        decoder.DisallowUnknownFields()
+    }
+
+    if err := decoder.Decode(v); err != nil {
+        return err
+    }
+
+    if _, err := decoder.Token(); err != io.EOF {
+        if restError, ok := err.(*rest_error.Error); ok {
+            return restError
+        }
+        return errTrailingData
+    }
+
+    return nil
+}
+
+func (YamlCodec) Decode(reader io.Reader, v interface{}, strict bool) error {
+    data, err := ioutil.ReadAll(reader)
+    if err != nil {
+        return err
+    }
+
+    if err := checkSingleYamlDocument(data); err != nil {
+        return err
+    }
+
+    if strict {
+        return yaml.UnmarshalStrict(data, v, yaml.DisallowUnknownFields)
+    }
+    return yaml.Unmarshal(data, v)
+}
+
+// checkSingleYamlDocument rejects a second document, even an empty one; malformed input is left to Unmarshal
+func checkSingleYamlDocument(data []byte) error {
+    decoder := yamlv2.NewDecoder(bytes.NewReader(data))
+
+    var document interface{}
+    if err := decoder.Decode(&document); err != nil {
+        return nil
+    }
+
+    if err := decoder.Decode(&document); err != io.EOF {
+        return errTrailingData
+    }
+
+    return nil
+}
+
+func (server *Server) MaxBodySize(size int64) *Server {
+    server.maxBodySize = size
+    return server
+}
+
+func (server *Server) StrictDecoding(strict bool) *Server {
+    server.settings.strictDecoding = strict
+    return server
+}
+
+func (collection *Collection) MaxBodySize(size int64) *Collection {
+    collection.maxBodySize = size
+    return collection
+}
+
+func (collection *Collection) effectiveMaxBodySize() int64 {
+    for current := collection; current != nil; current = current.parent {
+        if current.maxBodySize != 0 {
+            return current.maxBodySize
+        }
+    }
+    for current := collection.server; current != nil; current = current.parent {
+        if current.maxBodySize != 0 {
+            return current.maxBodySize
+        }
+    }
+    return NoBodyLimit
+}
+
+func (collection *Collection) limitBody(request *Request, response http.ResponseWriter) bool {
+    limit := collection.effectiveMaxBodySize()
+    if limit < 0 || request.Body == nil {
+        return true
+    }
+
+    if request.ContentLength > limit {
+        writeError(response, request.Request, bodyTooLarge(limit))
+        return false
+    }
+
+    request.Body = &limitedBody{
+        ReadCloser: request.Body,
+        remaining:  limit,
+        limit:      limit,
+    }
+
+    return true
+}
+
+type limitedBody struct {
+    io.ReadCloser
+
+    remaining int64
+    limit     int64
+}
+
+func (body *limitedBody) Read(p []byte) (int, error) {
+    if body.remaining < 0 {
+        return 0, bodyTooLarge(body.limit)
+    }
+
+    if int64(len(p)) > body.remaining + 1 {
+        p = p[:body.remaining + 1]
+    }
+
+    n, err := body.ReadCloser.Read(p)
+    if int64(n) <= body.remaining {
+        body.remaining -= int64(n)
+        return n, err
+    }
+
+    n = int(body.remaining)
+    body.remaining = -1
+
+    return n, bodyTooLarge(body.limit)
+}
+
+func bodyTooLarge(limit int64) error {
+    return rest_error.New(http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body must not exceed %d bytes", limit))
+}
+
+func (r *Request) ReadBody() ([]byte, error) {
+    return ioutil.ReadAll(r.Body)
+}
+
+func (r *Request) Decode(v interface{}) error {
+    return r.decode(r.Body, v)
+}
+
+func (r *Request) strictDecoding() bool {
+    return r.server != nil && r.server.settings.strictDecoding
+}
+
+func (r *Request) decode(reader io.Reader, v interface{}) error {
+    codec, err := r.RequestCodec()
+    if err != nil {
+        return err
+    }
+
+    if streamCodec, ok := codec.(StreamCodec); ok {
+        err = streamCodec.Decode(reader, v, r.strictDecoding())
+    } else {
+        var data []byte
+        if data, err = ioutil.ReadAll(reader); err == nil {
+            err = codec.Unmarshal(data, v)
+        }
+    }
+
+    return malformedBody(err)
+}
+
+func decodeJson(request *Request, data []byte, v interface{}) error {
+    return malformedBody(JsonCodec{}.Decode(bytes.NewReader(data), v, request.strictDecoding()))
+}
+
+func malformedBody(err error) error {
+    if err == nil {
+        return nil
+    }
+    if restError, ok := err.(*rest_error.Error); ok {
+        return restError
+    }
+    return rest_error.NewWithReason(http.StatusBadRequest, rest_error.ReasonMalformedBody, err.Error())
+}
@@ -0,0 +1,73 @@
+package rest
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func TestDecodeRejectsTrailingData(t *testing.T) {
+    tests := []struct {
+        contentType string
+        body        string
+        valid       bool
+    }{
+        {contentType: JsonContentType, body: `{"name":"a"}`, valid: true},
+        {contentType: JsonContentType, body: "{\"name\":\"a\"}\n ", valid: true},
+        {contentType: JsonContentType, body: `{"name":"a"} {"name":"b"}`, valid: false},
+        {contentType: YamlContentType, body: "name: a\n", valid: true},
+        {contentType: YamlContentType, body: "---\nname: a\n...\n", valid: true},
+        {contentType: YamlContentType, body: "name: a\n---\nname: b\n", valid: false},
+        {contentType: YamlContentType, body: "name: a\n---\n", valid: false},
+    }
+
+    for _, test := range tests {
+        request := &Request{Request: httptest.NewRequest("POST", "/", strings.NewReader(test.body))}
+        request.Header.Set("Content-Type", test.contentType)
+
+        item := &struct {
+            Name string `json:"name"`
+        }{}
+
+        if err := request.Decode(item); (err == nil) != test.valid {
+            t.Errorf("%s %q: expected valid %v, got %v", test.contentType, test.body, test.valid, err)
+        }
+    }
+}
+
+func TestBodyIsNotLimitedByDefault(t *testing.T) {
+    var received int
+
+    server := NewServer()
+    server.Collection("uploads").CustomHandlerFunc(func(request *Request, response http.ResponseWriter) {
+        content, err := request.ReadBody()
+        if err != nil {
+            writeError(response, request.Request, err)
+            return
+        }
+        received = len(content)
+    })
+
+    limited := server.Collection("limited").MaxBodySize(16)
+    limited.CustomHandlerFunc(func(request *Request, response http.ResponseWriter) {
+        if _, err := request.ReadBody(); err != nil {
+            writeError(response, request.Request, err)
+        }
+    })
+
+    body := bytes.Repeat([]byte{'x'}, int(DefaultMaxBodySize) + 1)
+
+    response := httptest.NewRecorder()
+    server.mux.ServeHTTP(response, httptest.NewRequest("POST", "/uploads", bytes.NewReader(body)))
+    if response.Code != http.StatusOK || received != len(body) {
+        t.Errorf("unexpected status %d after reading %d bytes", response.Code, received)
+    }
+
+    response = httptest.NewRecorder()
+    server.mux.ServeHTTP(response, httptest.NewRequest("POST", "/limited", bytes.NewReader(body)))
+    if response.Code != http.StatusRequestEntityTooLarge {
+        t.Errorf("expected 413 for the limited collection, got %d", response.Code)
+    }
+}
@@ -0,0 +1,81 @@
+package rest_client
+
+import (
+    "context"
+    "encoding/json"
+    "github.com/maxmanuylov/go-rest/error"
+    "io/ioutil"
+)
+
+type BatchMode string
+
+const (
+    BestEffort   BatchMode = "best-effort"
+    AllOrNothing BatchMode = "all-or-nothing"
+)
+
+type BatchResult struct {
+    Index    int               `json:"index"`
+    Status   int               `json:"status"`
+    ID       string            `json:"id,omitempty"`
+    Location string            `json:"location,omitempty"`
+    Error    *rest_error.Error `json:"error,omitempty"`
+}
+
+type BatchUpdate struct {
+    ID   string      `json:"id"`
+    Item interface{} `json:"item"`
+}
+
+func (collection *_collection) CreateMany(items interface{}, mode BatchMode) ([]*BatchResult, error) {
+    return collection.CreateManyCtx(context.Background(), items, mode)
+}
+
+func (collection *_collection) CreateManyCtx(ctx context.Context, items interface{}, mode BatchMode) ([]*BatchResult, error) {
+    collection, err := collection.withIdempotencyKey()
+    if err != nil {
+        return nil, err
+    }
+    return collection.doBatch(ctx, "POST", items, mode)
+}
+
+func (collection *_collection) UpdateMany(updates []*BatchUpdate, mode BatchMode) ([]*BatchResult, error) {
+    return collection.UpdateManyCtx(context.Background(), updates, mode)
+}
+
+func (collection *_collection) UpdateManyCtx(ctx context.Context, updates []*BatchUpdate, mode BatchMode) ([]*BatchResult, error) {
+    return collection.doBatch(ctx, "PATCH", updates, mode)
+}
+
+func (collection *_collection) doBatch(ctx context.Context, method string, items interface{}, mode BatchMode) ([]*BatchResult, error) {
+    itemsJson, err := json.Marshal(items)
+    if err != nil {
+        return nil, err
+    }
+
+    batchCollection := collection
+    if method == "POST" {
+        batchCollection = batchCollection.WithParam("batch", "true").(*_collection)
+    }
+    if mode != "" {
+        batchCollection = batchCollection.WithParam("mode", string(mode)).(*_collection)
+    }
+
+    response, err := batchCollection.do(ctx, method, collection.path, Json, itemsJson)
+    if err != nil || response == nil {
+        return nil, err
+    }
+    defer response.Body.Close()
+
+    resultsJson, err := ioutil.ReadAll(response.Body)
+    if err != nil {
+        return nil, err
+    }
+
+    results := make([]*BatchResult, 0)
+    if err := json.Unmarshal(resultsJson, &results); err != nil {
+        return nil, err
+    }
+
+    return results, nil
+}
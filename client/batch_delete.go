@@ -0,0 +1,62 @@
+package rest_client
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io/ioutil"
+    "strings"
+)
+
+type deleteResult struct {
+    Deleted *int `json:"deleted"`
+}
+
+// DeleteIDs and DeleteWhere return the number of deleted items, or -1 if the server does not report it.
+func (collection *_collection) DeleteIDs(ids... string) (int, error) {
+    return collection.DeleteIDsCtx(context.Background(), ids...)
+}
+
+func (collection *_collection) DeleteIDsCtx(ctx context.Context, ids... string) (int, error) {
+    if len(ids) == 0 {
+        return 0, nil
+    }
+    return collection.WithParam("ids", strings.Join(ids, ",")).(*_collection).doBatchDelete(ctx)
+}
+
+func (collection *_collection) DeleteWhere(filters map[string]string) (int, error) {
+    return collection.DeleteWhereCtx(context.Background(), filters)
+}
+
+func (collection *_collection) DeleteWhereCtx(ctx context.Context, filters map[string]string) (int, error) {
+    if len(filters) == 0 {
+        return 0, fmt.Errorf("DeleteWhere requires at least one filter")
+    }
+
+    filtered := collection
+    for field, value := range filters {
+        filtered = filtered.WithParam(fmt.Sprintf("filter[%s]", field), value).(*_collection)
+    }
+
+    return filtered.doBatchDelete(ctx)
+}
+
+func (collection *_collection) doBatchDelete(ctx context.Context) (int, error) {
+    response, err := collection.do(ctx, "DELETE", collection.path, Json, nil)
+    if err != nil || response == nil {
+        return 0, err
+    }
+    defer response.Body.Close()
+
+    resultJson, err := ioutil.ReadAll(response.Body)
+    if err != nil {
+        return 0, err
+    }
+
+    result := &deleteResult{}
+    if len(resultJson) == 0 || json.Unmarshal(resultJson, result) != nil || result.Deleted == nil {
+        return -1, nil
+    }
+
+    return *result.Deleted, nil
+}
@@ -2,6 +2,7 @@ package rest_client
 
 import (
     "bytes"
+    "context"
     "fmt"
     "github.com/maxmanuylov/go-rest/error"
     "io"
@@ -16,8 +17,13 @@ const (
 )
 
 type Client struct {
-    serverUrl  string
-    httpClient *http.Client
+    serverUrl   string
+    httpClient  *http.Client
+    retryPolicy *RetryPolicy
+    breakers    *circuitBreakers
+
+    interceptors    []Interceptor
+    idempotencyKeys bool
 }
 
 func New(serverUrl string, httpClient *http.Client) *Client {
@@ -28,7 +34,9 @@ func New(serverUrl string, httpClient *http.Client) *Client {
 }
 
 func (client *Client) WithPrefix(prefix string) *Client {
-    return New(client.path(prefix), client.httpClient)
+    prefixed := *client
+    prefixed.serverUrl = strings.TrimSuffix(client.path(prefix), "/")
+    return &prefixed
 }
 
 type Header struct {
@@ -37,27 +45,100 @@ type Header struct {
 }
 
 func (client *Client) Do(method, path, contentType string, content []byte, additionalHeaders... *Header) (*http.Response, error) {
+    return client.DoCtx(context.Background(), method, path, contentType, content, additionalHeaders...)
+}
+
+func (client *Client) DoCtx(ctx context.Context, method, path, contentType string, content []byte, additionalHeaders... *Header) (*http.Response, error) {
     var contentReader io.Reader
     if content != nil {
         contentReader = bytes.NewReader(content)
     }
-    return client.DoStream(method, path, contentType, contentReader, additionalHeaders...)
+    return client.DoStreamCtx(ctx, method, path, contentType, contentReader, additionalHeaders...)
 }
 
 func (client *Client) DoStream(method, path, contentType string, contentReader io.Reader, additionalHeaders... *Header) (*http.Response, error) {
-    request, err := http.NewRequest(method, client.path(path), contentReader)
+    return client.DoStreamCtx(context.Background(), method, path, contentType, contentReader, additionalHeaders...)
+}
+
+func (client *Client) DoStreamCtx(ctx context.Context, method, path, contentType string, contentReader io.Reader, additionalHeaders... *Header) (*http.Response, error) {
+    return client.doUrl(ctx, method, client.path(path), contentType, contentReader, additionalHeaders...)
+}
+
+func (client *Client) doUrl(ctx context.Context, method, requestUrl, contentType string, contentReader io.Reader, additionalHeaders... *Header) (*http.Response, error) {
+    maxAttempts := client.maxAttempts(method, contentReader, additionalHeaders)
+
+    var body *rewindableBody
+    if maxAttempts > 1 && contentReader != nil {
+        var err error
+        if body, err = newRewindableBody(contentReader); err != nil {
+            return nil, err
+        }
+        contentReader = body
+    }
+
+    for attempt := 1; ; attempt++ {
+        response, err := client.send(ctx, method, requestUrl, contentType, contentReader, additionalHeaders)
+
+        if attempt >= maxAttempts || !client.retryPolicy.shouldRetry(ctx, response, err) {
+            if err != nil {
+                return nil, err
+            }
+            return checkResponse(response)
+        }
+
+        delay, ok := client.retryPolicy.delay(attempt, response)
+        if !ok {
+            if err != nil {
+                return nil, err
+            }
+            return checkResponse(response)
+        }
+
+        discard(response)
+
+        if err := sleep(ctx, delay); err != nil {
+            return nil, err
+        }
+
+        if body != nil {
+            if err := body.rewind(); err != nil {
+                return nil, err
+            }
+        }
+    }
+}
+
+func (client *Client) send(ctx context.Context, method, requestUrl, contentType string, contentReader io.Reader, additionalHeaders []*Header) (*http.Response, error) {
+    if !client.breakers.allow(requestUrl) {
+        return nil, ErrCircuitOpen
+    }
+
+    request, err := http.NewRequestWithContext(ctx, method, requestUrl, contentReader)
     if err != nil {
+        client.breakers.release(requestUrl)
         return nil, err
     }
 
+    if body, ok := contentReader.(*rewindableBody); ok {
+        if request.ContentLength = body.length; body.length == 0 {
+            request.Body = http.NoBody
+        } else {
+            request.GetBody = func() (io.ReadCloser, error) {
+                return ioutil.NopCloser(body), body.rewind()
+            }
+        }
+    }
+
     if contentType != "" {
         if contentReader != nil {
             request.Header.Add("Content-Type", contentType)
         }
         request.Header.Add("Accept", contentType)
+    } else {
+        request.Header.Add("Accept", Json)
     }
 
-    request.Header.Add("User-Agent", "curl/7.43.0")
+    request.Header.Add("User-Agent", DefaultUserAgent)
 
     for _, header := range additionalHeaders {
         if header.Name != "" && header.Values != nil {
@@ -65,11 +146,22 @@ func (client *Client) DoStream(method, path, contentType string, contentReader i
         }
     }
 
-    response, err := client.httpClient.Do(request)
-    if err != nil {
-        return nil, err
+    response, err := client.roundTrip(request)
+
+    switch {
+    case err == nil:
+        client.breakers.record(requestUrl, response.StatusCode >= 500)
+    case ctx.Err() == nil && transientError(err):
+        client.breakers.record(requestUrl, true)
+    default:
+        // cancelled by the caller or failed before reaching the host, this says nothing about the host
+        client.breakers.release(requestUrl)
     }
 
+    return response, err
+}
+
+func checkResponse(response *http.Response) (*http.Response, error) {
     if response.StatusCode / 100 == 2 {
         return response, nil
     }
@@ -78,6 +170,9 @@ func (client *Client) DoStream(method, path, contentType string, contentReader i
     message, err := ioutil.ReadAll(response.Body)
 
     if err == nil && message != nil {
+        if restErr, ok := rest_error.Decode(response.StatusCode, response.Header.Get("Content-Type"), message); ok {
+            return nil, restErr
+        }
         return nil, rest_error.New(response.StatusCode, fmt.Sprintf("\n%s", string(message)))
     } else {
         return nil, rest_error.NewByCode(response.StatusCode)
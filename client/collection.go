@@ -1,6 +1,7 @@
 package rest_client
 
 import (
+    "context"
     "encoding/json"
     "fmt"
     "github.com/maxmanuylov/go-rest/error"
@@ -13,13 +14,16 @@ import (
 
 type Doable interface {
     Do(method, contentType string, content []byte) (*http.Response, error)
+    DoCtx(ctx context.Context, method, contentType string, content []byte) (*http.Response, error)
     DoStream(method, contentType string, contentReader io.Reader) (*http.Response, error)
+    DoStreamCtx(ctx context.Context, method, contentType string, contentReader io.Reader) (*http.Response, error)
 }
 
 type CollectionItem interface {
     Doable
 
     DoAction(method string) error
+    DoActionCtx(ctx context.Context, method string) error
 }
 
 type Collection interface {
@@ -33,28 +37,69 @@ type Collection interface {
     Item(itemId string) CollectionItem
 
     List(items interface{}) error
+    ListCtx(ctx context.Context, items interface{}) error
     ListJson() ([]byte, error)
+    ListJsonCtx(ctx context.Context) ([]byte, error)
+    Pages(pageSize int) *PageIterator
+    PagesCtx(ctx context.Context, pageSize int) *PageIterator
+    Items() *ItemIterator
+    ItemsCtx(ctx context.Context) *ItemIterator
+    ListEach(newItem func() interface{}, each func(item interface{}) error) error
+    ListEachCtx(ctx context.Context, newItem func() interface{}, each func(item interface{}) error) error
     ListYaml() ([]byte, error)
+    ListYamlCtx(ctx context.Context) ([]byte, error)
 
     Exists(id string) (bool, error)
+    ExistsCtx(ctx context.Context, id string) (bool, error)
 
     Get(id string, item interface{}) error
+    GetCtx(ctx context.Context, id string, item interface{}) error
     GetJson(id string) ([]byte, error)
+    GetJsonCtx(ctx context.Context, id string) ([]byte, error)
     GetYaml(id string) ([]byte, error)
+    GetYamlCtx(ctx context.Context, id string) ([]byte, error)
+    GetWithVersion(id string, item interface{}) (string, error)
+    GetWithVersionCtx(ctx context.Context, id string, item interface{}) (string, error)
 
     Create(item interface{}) (string, error)
+    CreateCtx(ctx context.Context, item interface{}) (string, error)
     CreateJson(itemJson []byte) (string, error)
+    CreateJsonCtx(ctx context.Context, itemJson []byte) (string, error)
     CreateYaml(itemYaml []byte) (string, error)
+    CreateYamlCtx(ctx context.Context, itemYaml []byte) (string, error)
 
     Update(id string, item interface{}) error
+    UpdateCtx(ctx context.Context, id string, item interface{}) error
     UpdateJson(id string, itemJson []byte) error
+    UpdateJsonCtx(ctx context.Context, id string, itemJson []byte) error
     UpdateYaml(id string, itemYaml []byte) error
+    UpdateYamlCtx(ctx context.Context, id string, itemYaml []byte) error
 
     Replace(id string, item interface{}) error
+    ReplaceCtx(ctx context.Context, id string, item interface{}) error
     ReplaceJson(id string, itemJson []byte) error
+    ReplaceJsonCtx(ctx context.Context, id string, itemJson []byte) error
     ReplaceYaml(id string, itemYaml []byte) error
+    ReplaceYamlCtx(ctx context.Context, id string, itemYaml []byte) error
+    ReplaceIfMatch(id, version string, item interface{}) error
+    ReplaceIfMatchCtx(ctx context.Context, id, version string, item interface{}) error
+
+    CreateMany(items interface{}, mode BatchMode) ([]*BatchResult, error)
+    CreateManyCtx(ctx context.Context, items interface{}, mode BatchMode) ([]*BatchResult, error)
+    UpdateMany(updates []*BatchUpdate, mode BatchMode) ([]*BatchResult, error)
+    UpdateManyCtx(ctx context.Context, updates []*BatchUpdate, mode BatchMode) ([]*BatchResult, error)
+
+    MergePatch(id string, patch interface{}) error
+    MergePatchCtx(ctx context.Context, id string, patch interface{}) error
+    JSONPatch(id string, operations... *PatchOperation) error
+    JSONPatchCtx(ctx context.Context, id string, operations... *PatchOperation) error
 
     Delete(id string) error
+    DeleteCtx(ctx context.Context, id string) error
+    DeleteIDs(ids... string) (int, error)
+    DeleteIDsCtx(ctx context.Context, ids... string) (int, error)
+    DeleteWhere(filters map[string]string) (int, error)
+    DeleteWhereCtx(ctx context.Context, filters map[string]string) (int, error)
 }
 
 type _collection struct {
@@ -153,23 +198,41 @@ func (collection *_collection) Item(itemId string) CollectionItem {
 }
 
 func (collection *_collection) List(items interface{}) error {
-    itemsJson, err := collection.ListJson()
-    if err != nil || itemsJson == nil {
+    return collection.ListCtx(context.Background(), items)
+}
+
+func (collection *_collection) ListCtx(ctx context.Context, items interface{}) error {
+    response, err := collection.do(ctx, "GET", collection.path, Json, nil)
+    if err != nil || response == nil {
         return err
     }
-    return json.Unmarshal(itemsJson, items)
+    defer response.Body.Close()
+
+    return json.NewDecoder(response.Body).Decode(items)
 }
 
 func (collection *_collection) ListJson() ([]byte, error) {
-    return collection.doGet(collection.path, Json)
+    return collection.ListJsonCtx(context.Background())
+}
+
+func (collection *_collection) ListJsonCtx(ctx context.Context) ([]byte, error) {
+    return collection.doGet(ctx, collection.path, Json)
 }
 
 func (collection *_collection) ListYaml() ([]byte, error) {
-    return collection.doGet(collection.path, Yaml)
+    return collection.ListYamlCtx(context.Background())
+}
+
+func (collection *_collection) ListYamlCtx(ctx context.Context) ([]byte, error) {
+    return collection.doGet(ctx, collection.path, Yaml)
 }
 
 func (collection *_collection) Exists(id string) (bool, error) {
-    itemJson, err := collection.Ignoring(http.StatusNotFound).GetJson(id)
+    return collection.ExistsCtx(context.Background(), id)
+}
+
+func (collection *_collection) ExistsCtx(ctx context.Context, id string) (bool, error) {
+    itemJson, err := collection.Ignoring(http.StatusNotFound).(*_collection).doGet(ctx, collection.itemPath(id), Json)
     if err != nil || itemJson == nil {
         return false, err
     }
@@ -177,7 +240,11 @@ func (collection *_collection) Exists(id string) (bool, error) {
 }
 
 func (collection *_collection) Get(id string, item interface{}) error {
-    itemJson, err := collection.GetJson(id)
+    return collection.GetCtx(context.Background(), id, item)
+}
+
+func (collection *_collection) GetCtx(ctx context.Context, id string, item interface{}) error {
+    itemJson, err := collection.doGet(ctx, collection.itemPath(id), Json)
     if err != nil || itemJson == nil {
         return err
     }
@@ -185,15 +252,42 @@ func (collection *_collection) Get(id string, item interface{}) error {
 }
 
 func (collection *_collection) GetJson(id string) ([]byte, error) {
-    return collection.doGet(collection.itemPath(id), Json)
+    return collection.GetJsonCtx(context.Background(), id)
+}
+
+func (collection *_collection) GetJsonCtx(ctx context.Context, id string) ([]byte, error) {
+    return collection.doGet(ctx, collection.itemPath(id), Json)
 }
 
 func (collection *_collection) GetYaml(id string) ([]byte, error) {
-    return collection.doGet(collection.itemPath(id), Yaml)
+    return collection.GetYamlCtx(context.Background(), id)
+}
+
+func (collection *_collection) GetYamlCtx(ctx context.Context, id string) ([]byte, error) {
+    return collection.doGet(ctx, collection.itemPath(id), Yaml)
 }
 
-func (collection *_collection) doGet(path, contentType string) ([]byte, error) {
-    response, err := collection.do("GET", path, contentType, nil)
+func (collection *_collection) GetWithVersion(id string, item interface{}) (string, error) {
+    return collection.GetWithVersionCtx(context.Background(), id, item)
+}
+
+func (collection *_collection) GetWithVersionCtx(ctx context.Context, id string, item interface{}) (string, error) {
+    response, err := collection.do(ctx, "GET", collection.itemPath(id), Json, nil)
+    if err != nil || response == nil {
+        return "", err
+    }
+    defer response.Body.Close()
+
+    itemJson, err := ioutil.ReadAll(response.Body)
+    if err != nil {
+        return "", err
+    }
+
+    return response.Header.Get("ETag"), json.Unmarshal(itemJson, item)
+}
+
+func (collection *_collection) doGet(ctx context.Context, path, contentType string) ([]byte, error) {
+    response, err := collection.do(ctx, "GET", path, contentType, nil)
     if err != nil || response == nil {
         return nil, err
     }
@@ -203,12 +297,16 @@ func (collection *_collection) doGet(path, contentType string) ([]byte, error) {
 }
 
 func (collection *_collection) Create(item interface{}) (string, error) {
+    return collection.CreateCtx(context.Background(), item)
+}
+
+func (collection *_collection) CreateCtx(ctx context.Context, item interface{}) (string, error) {
     itemJson, err := json.Marshal(item)
     if err != nil {
         return "", err
     }
 
-    response, id, err := collection.doCreate(Json, itemJson)
+    response, id, err := collection.doCreate(ctx, Json, itemJson)
     if response != nil && err == http.ErrNoLocation {
         if newItemJson, err2 := ioutil.ReadAll(response.Body); err2 == nil {
             if json.Unmarshal(newItemJson, item) == nil {
@@ -221,17 +319,30 @@ func (collection *_collection) Create(item interface{}) (string, error) {
 }
 
 func (collection *_collection) CreateJson(itemJson []byte) (string, error) {
-    response, id, err := collection.doCreate(Json, itemJson)
+    return collection.CreateJsonCtx(context.Background(), itemJson)
+}
+
+func (collection *_collection) CreateJsonCtx(ctx context.Context, itemJson []byte) (string, error) {
+    response, id, err := collection.doCreate(ctx, Json, itemJson)
     return id, CloseResponse(response, err)
 }
 
 func (collection *_collection) CreateYaml(itemYaml []byte) (string, error) {
-    response, id, err := collection.doCreate(Yaml, itemYaml)
+    return collection.CreateYamlCtx(context.Background(), itemYaml)
+}
+
+func (collection *_collection) CreateYamlCtx(ctx context.Context, itemYaml []byte) (string, error) {
+    response, id, err := collection.doCreate(ctx, Yaml, itemYaml)
     return id, CloseResponse(response, err)
 }
 
-func (collection *_collection) doCreate(contentType string, itemContent []byte) (*http.Response, string, error) {
-    response, err := collection.do("POST", collection.path, contentType, itemContent)
+func (collection *_collection) doCreate(ctx context.Context, contentType string, itemContent []byte) (*http.Response, string, error) {
+    collection, err := collection.withIdempotencyKey()
+    if err != nil {
+        return nil, "", err
+    }
+
+    response, err := collection.do(ctx, "POST", collection.path, contentType, itemContent)
     if err != nil || response == nil {
         return nil, "", err
     }
@@ -249,70 +360,129 @@ func (collection *_collection) doCreate(contentType string, itemContent []byte)
 }
 
 func (collection *_collection) Update(id string, item interface{}) error {
+    return collection.UpdateCtx(context.Background(), id, item)
+}
+
+func (collection *_collection) UpdateCtx(ctx context.Context, id string, item interface{}) error {
     itemJson, err := json.Marshal(item)
     if err != nil {
         return err
     }
-    return collection.UpdateJson(id, itemJson)
+    return collection.doUpdate(ctx, id, Json, itemJson)
 }
 
 func (collection *_collection) UpdateJson(id string, itemJson []byte) error {
-    return collection.doUpdate(id, Json, itemJson)
+    return collection.UpdateJsonCtx(context.Background(), id, itemJson)
+}
+
+func (collection *_collection) UpdateJsonCtx(ctx context.Context, id string, itemJson []byte) error {
+    return collection.doUpdate(ctx, id, Json, itemJson)
 }
 
 func (collection *_collection) UpdateYaml(id string, itemYaml []byte) error {
-    return collection.doUpdate(id, Yaml, itemYaml)
+    return collection.UpdateYamlCtx(context.Background(), id, itemYaml)
 }
 
-func (collection *_collection) doUpdate(id, contentType string, itemContent []byte) error {
-    return CloseResponse(collection.do("POST", collection.itemPath(id), contentType, itemContent))
+func (collection *_collection) UpdateYamlCtx(ctx context.Context, id string, itemYaml []byte) error {
+    return collection.doUpdate(ctx, id, Yaml, itemYaml)
+}
+
+func (collection *_collection) doUpdate(ctx context.Context, id, contentType string, itemContent []byte) error {
+    return CloseResponse(collection.do(ctx, "POST", collection.itemPath(id), contentType, itemContent))
 }
 
 func (collection *_collection) Replace(id string, item interface{}) error {
+    return collection.ReplaceCtx(context.Background(), id, item)
+}
+
+func (collection *_collection) ReplaceCtx(ctx context.Context, id string, item interface{}) error {
     itemJson, err := json.Marshal(item)
     if err != nil {
         return err
     }
-    return collection.ReplaceJson(id, itemJson)
+    return collection.doReplace(ctx, id, Json, itemJson)
 }
 
 func (collection *_collection) ReplaceJson(id string, itemJson []byte) error {
-    return collection.doReplace(id, Json, itemJson)
+    return collection.ReplaceJsonCtx(context.Background(), id, itemJson)
+}
+
+func (collection *_collection) ReplaceJsonCtx(ctx context.Context, id string, itemJson []byte) error {
+    return collection.doReplace(ctx, id, Json, itemJson)
 }
 
 func (collection *_collection) ReplaceYaml(id string, itemYaml []byte) error {
-    return collection.doReplace(id, Yaml, itemYaml)
+    return collection.ReplaceYamlCtx(context.Background(), id, itemYaml)
+}
+
+func (collection *_collection) ReplaceYamlCtx(ctx context.Context, id string, itemYaml []byte) error {
+    return collection.doReplace(ctx, id, Yaml, itemYaml)
+}
+
+func (collection *_collection) ReplaceIfMatch(id, version string, item interface{}) error {
+    return collection.ReplaceIfMatchCtx(context.Background(), id, version, item)
+}
+
+func (collection *_collection) ReplaceIfMatchCtx(ctx context.Context, id, version string, item interface{}) error {
+    itemJson, err := json.Marshal(item)
+    if err != nil {
+        return err
+    }
+    return collection.WithHeader("If-Match", quoteVersion(version)).(*_collection).doReplace(ctx, id, Json, itemJson)
+}
+
+func quoteVersion(version string) string {
+    if version == "*" || strings.HasPrefix(version, "\"") || strings.HasPrefix(version, "W/\"") {
+        return version
+    }
+    return fmt.Sprintf("\"%s\"", version)
 }
 
-func (collection *_collection) doReplace(id, contentType string, itemContent []byte) error {
-    return CloseResponse(collection.do("PUT", collection.itemPath(id), contentType, itemContent))
+func (collection *_collection) doReplace(ctx context.Context, id, contentType string, itemContent []byte) error {
+    return CloseResponse(collection.do(ctx, "PUT", collection.itemPath(id), contentType, itemContent))
 }
 
 func (collection *_collection) Delete(id string) error {
-    return CloseResponse(collection.do("DELETE", collection.itemPath(id), "", nil))
+    return collection.DeleteCtx(context.Background(), id)
+}
+
+func (collection *_collection) DeleteCtx(ctx context.Context, id string) error {
+    return CloseResponse(collection.do(ctx, "DELETE", collection.itemPath(id), "", nil))
 }
 
 func (collection *_collection) Do(method, contentType string, content []byte) (*http.Response, error) {
-    return collection.do(method, collection.path, contentType, content)
+    return collection.DoCtx(context.Background(), method, contentType, content)
+}
+
+func (collection *_collection) DoCtx(ctx context.Context, method, contentType string, content []byte) (*http.Response, error) {
+    return collection.do(ctx, method, collection.path, contentType, content)
 }
 
 func (collection *_collection) DoStream(method, contentType string, contentReader io.Reader) (*http.Response, error) {
-    return collection.doStream(method, collection.path, contentType, contentReader)
+    return collection.DoStreamCtx(context.Background(), method, contentType, contentReader)
+}
+
+func (collection *_collection) DoStreamCtx(ctx context.Context, method, contentType string, contentReader io.Reader) (*http.Response, error) {
+    return collection.doStream(ctx, method, collection.path, contentType, contentReader)
 }
 
 func (collection *_collection) DoAction(method string) error {
-    return CloseResponse(collection.do(method, collection.path, "", nil))
+    return collection.DoActionCtx(context.Background(), method)
+}
+
+func (collection *_collection) DoActionCtx(ctx context.Context, method string) error {
+    return CloseResponse(collection.do(ctx, method, collection.path, "", nil))
 }
 
-func (collection *_collection) do(method, path, contentType string, content []byte) (*http.Response, error) {
+func (collection *_collection) do(ctx context.Context, method, path, contentType string, content []byte) (*http.Response, error) {
     return collection.doRequest(path, func(queryPath string) (*http.Response, error) {
-        return collection.client.Do(method, queryPath, contentType, content, collection.headers...)
+        return collection.client.DoCtx(ctx, method, queryPath, contentType, content, collection.headers...)
     })
 }
 
-func (collection *_collection) doStream(method, path, contentType string, contentReader io.Reader) (*http.Response, error) {
+func (collection *_collection) doStream(ctx context.Context, method, path, contentType string, contentReader io.Reader) (*http.Response, error) {
     return collection.doRequest(path, func(queryPath string) (*http.Response, error) {
-        return collection.client.DoStream(method, queryPath, contentType, contentReader, collection.headers...)
+        return collection.client.DoStreamCtx(ctx, method, queryPath, contentType, contentReader, collection.headers...)
     })
 }
 
@@ -0,0 +1,42 @@
+package rest_client
+
+import (
+    "crypto/rand"
+    "fmt"
+)
+
+const (
+    IdempotencyKeyHeader = "Idempotency-Key"
+)
+
+// SetIdempotencyKeys makes Create and CreateMany send a generated Idempotency-Key header unless one is already set,
+// which also allows the retry policy to retry creates.
+func (client *Client) SetIdempotencyKeys(enabled bool) *Client {
+    client.idempotencyKeys = enabled
+    return client
+}
+
+func NewIdempotencyKey() (string, error) {
+    uuid := make([]byte, 16)
+    if _, err := rand.Read(uuid); err != nil {
+        return "", err
+    }
+
+    uuid[6] = uuid[6] & 0x0f | 0x40
+    uuid[8] = uuid[8] & 0x3f | 0x80
+
+    return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[0:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:]), nil
+}
+
+func (collection *_collection) withIdempotencyKey() (*_collection, error) {
+    if !collection.client.idempotencyKeys || hasHeader(collection.headers, IdempotencyKeyHeader) {
+        return collection, nil
+    }
+
+    key, err := NewIdempotencyKey()
+    if err != nil {
+        return nil, err
+    }
+
+    return collection.WithHeader(IdempotencyKeyHeader, key).(*_collection), nil
+}
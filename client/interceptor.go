@@ -0,0 +1,169 @@
+package rest_client
+
+import (
+    "context"
+    "github.com/maxmanuylov/go-rest/error"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "sync"
+    "time"
+)
+
+const (
+    DefaultUserAgent = "curl/7.43.0"
+
+    tokenExpirySkew = 10 * time.Second
+)
+
+type RoundTrip func(request *http.Request) (*http.Response, error)
+
+// Interceptor wraps every attempt of a request: it may change the request before calling next
+// and inspect or replace the response afterwards. Interceptors run in Use order, the first one being the outermost.
+type Interceptor func(next RoundTrip) RoundTrip
+
+func (client *Client) Use(interceptors... Interceptor) *Client {
+    client.interceptors = append(client.interceptors[:len(client.interceptors):len(client.interceptors)], interceptors...)
+    return client
+}
+
+func (client *Client) roundTrip(request *http.Request) (*http.Response, error) {
+    roundTrip := RoundTrip(client.httpClient.Do)
+    for i := len(client.interceptors) - 1; i >= 0; i-- {
+        roundTrip = client.interceptors[i](roundTrip)
+    }
+    return roundTrip(request)
+}
+
+func UserAgent(userAgent string) Interceptor {
+    return HeaderInterceptor("User-Agent", userAgent)
+}
+
+func HeaderInterceptor(name, value string) Interceptor {
+    return func(next RoundTrip) RoundTrip {
+        return func(request *http.Request) (*http.Response, error) {
+            request.Header.Set(name, value)
+            return next(request)
+        }
+    }
+}
+
+func BasicAuth(username, password string) Interceptor {
+    return func(next RoundTrip) RoundTrip {
+        return func(request *http.Request) (*http.Response, error) {
+            request.SetBasicAuth(username, password)
+            return next(request)
+        }
+    }
+}
+
+// RequestID sets the request id header unless the request already has one; a nil generate produces random UUIDs.
+func RequestID(generate func() string) Interceptor {
+    return func(next RoundTrip) RoundTrip {
+        return func(request *http.Request) (*http.Response, error) {
+            if request.Header.Get(rest_error.RequestIdHeader) == "" {
+                var requestId string
+                if generate != nil {
+                    requestId = generate()
+                } else {
+                    var err error
+                    if requestId, err = NewIdempotencyKey(); err != nil {
+                        return nil, err
+                    }
+                }
+                request.Header.Set(rest_error.RequestIdHeader, requestId)
+            }
+            return next(request)
+        }
+    }
+}
+
+// TokenSource returns the current token; refresh is set when the server has rejected the previous one.
+type TokenSource func(ctx context.Context, refresh bool) (string, error)
+
+// BearerToken authorizes requests with tokens from source; after a 401 answer it asks for a fresh token
+// and repeats the request once, provided the request body can be replayed.
+func BearerToken(source TokenSource) Interceptor {
+    return func(next RoundTrip) RoundTrip {
+        return func(request *http.Request) (*http.Response, error) {
+            token, err := source(request.Context(), false)
+            if err != nil {
+                return nil, err
+            }
+
+            request.Header.Set("Authorization", "Bearer " + token)
+
+            response, err := next(request)
+            if err != nil || response.StatusCode != http.StatusUnauthorized || !replayable(request) {
+                return response, err
+            }
+
+            freshToken, err := source(request.Context(), true)
+            if err != nil || freshToken == token {
+                return response, err
+            }
+
+            retry, err := cloneRequest(request)
+            if err != nil {
+                return response, nil
+            }
+
+            io.Copy(ioutil.Discard, response.Body)
+            response.Body.Close()
+
+            retry.Header.Set("Authorization", "Bearer " + freshToken)
+
+            return next(retry)
+        }
+    }
+}
+
+// RefreshingToken caches the token returned by fetch until shortly before it expires
+// or until the server rejects it; a zero expiry means the token does not expire.
+func RefreshingToken(fetch func(ctx context.Context) (string, time.Time, error)) TokenSource {
+    var (
+        mutex   sync.Mutex
+        token   string
+        expires time.Time
+    )
+
+    return func(ctx context.Context, refresh bool) (string, error) {
+        mutex.Lock()
+        defer mutex.Unlock()
+
+        if token != "" && !refresh && (expires.IsZero() || time.Now().Add(tokenExpirySkew).Before(expires)) {
+            return token, nil
+        }
+
+        newToken, newExpires, err := fetch(ctx)
+        if err != nil {
+            return "", err
+        }
+
+        token, expires = newToken, newExpires
+
+        return token, nil
+    }
+}
+
+func StaticToken(token string) TokenSource {
+    return func(context.Context, bool) (string, error) {
+        return token, nil
+    }
+}
+
+func replayable(request *http.Request) bool {
+    return request.Body == nil || request.Body == http.NoBody || request.GetBody != nil
+}
+
+func cloneRequest(request *http.Request) (*http.Request, error) {
+    clone := request.Clone(request.Context())
+    if request.GetBody != nil {
+        body, err := request.GetBody()
+        if err != nil {
+            return nil, err
+        }
+        clone.Body = body
+    }
+    return clone, nil
+}
@@ -0,0 +1,145 @@
+package rest_client
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "github.com/maxmanuylov/go-rest/error"
+    "io"
+    "io/ioutil"
+    "net/http"
+)
+
+const (
+    StreamErrorTrailer = "X-Stream-Error"
+)
+
+// ItemIterator decodes list items one at a time straight from the response body.
+type ItemIterator struct {
+    ctx        context.Context
+    collection *_collection
+    response   *http.Response
+    decoder    *json.Decoder
+    started    bool
+    done       bool
+    err        error
+}
+
+func (collection *_collection) Items() *ItemIterator {
+    return collection.ItemsCtx(context.Background())
+}
+
+func (collection *_collection) ItemsCtx(ctx context.Context) *ItemIterator {
+    return &ItemIterator{
+        ctx:        ctx,
+        collection: collection,
+    }
+}
+
+func (collection *_collection) ListEach(newItem func() interface{}, each func(item interface{}) error) error {
+    return collection.ListEachCtx(context.Background(), newItem, each)
+}
+
+func (collection *_collection) ListEachCtx(ctx context.Context, newItem func() interface{}, each func(item interface{}) error) error {
+    iterator := collection.ItemsCtx(ctx)
+    defer iterator.Close()
+
+    for {
+        item := newItem()
+        if !iterator.Next(item) {
+            return iterator.Err()
+        }
+        if err := each(item); err != nil {
+            return err
+        }
+    }
+}
+
+func (iterator *ItemIterator) Next(item interface{}) bool {
+    if iterator.err != nil || iterator.done {
+        return false
+    }
+
+    if !iterator.started {
+        iterator.started = true
+        if !iterator.open() {
+            return false
+        }
+    }
+
+    if !iterator.decoder.More() {
+        if _, err := iterator.decoder.Token(); err != nil {
+            iterator.err = iterator.streamError(err)
+        }
+        iterator.Close()
+        return false
+    }
+
+    if err := iterator.decoder.Decode(item); err != nil {
+        iterator.err = iterator.streamError(err)
+        iterator.Close()
+        return false
+    }
+
+    return true
+}
+
+func (iterator *ItemIterator) open() bool {
+    collection := iterator.collection
+
+    response, err := collection.do(iterator.ctx, "GET", collection.path, Json, nil)
+    if err != nil || response == nil {
+        iterator.err = err
+        iterator.done = true
+        return false
+    }
+
+    iterator.response = response
+    iterator.decoder = json.NewDecoder(response.Body)
+
+    token, err := iterator.decoder.Token()
+    if err != nil {
+        iterator.err = err
+    } else if token == nil {
+        iterator.Close()
+        return false
+    } else if delim, ok := token.(json.Delim); !ok || delim != '[' {
+        iterator.err = fmt.Errorf("List response is not a JSON array")
+    }
+
+    if iterator.err != nil {
+        iterator.Close()
+        return false
+    }
+
+    return true
+}
+
+// streamError prefers the error the server reported in the trailer after failing mid-stream.
+func (iterator *ItemIterator) streamError(err error) error {
+    response := iterator.response
+
+    io.Copy(ioutil.Discard, response.Body)
+
+    if trailer := response.Trailer.Get(StreamErrorTrailer); trailer != "" {
+        if restErr, ok := rest_error.Decode(response.StatusCode, Json, []byte(trailer)); ok {
+            return restErr
+        }
+    }
+
+    return err
+}
+
+func (iterator *ItemIterator) Err() error {
+    return iterator.err
+}
+
+func (iterator *ItemIterator) Close() error {
+    iterator.done = true
+    if iterator.response != nil {
+        err := iterator.response.Body.Close()
+        iterator.response = nil
+        return err
+    }
+    return nil
+}
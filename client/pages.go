@@ -0,0 +1,119 @@
+package rest_client
+
+import (
+    "context"
+    "encoding/json"
+    "io/ioutil"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+)
+
+const (
+    TotalCountHeader = "X-Total-Count"
+)
+
+type PageIterator struct {
+    ctx        context.Context
+    collection *_collection
+    pageSize   int
+    nextUrl    *url.URL
+    started    bool
+    total      int
+    hasTotal   bool
+    err        error
+}
+
+func (collection *_collection) Pages(pageSize int) *PageIterator {
+    return collection.PagesCtx(context.Background(), pageSize)
+}
+
+func (collection *_collection) PagesCtx(ctx context.Context, pageSize int) *PageIterator {
+    return &PageIterator{
+        ctx:        ctx,
+        collection: collection,
+        pageSize:   pageSize,
+    }
+}
+
+func (iterator *PageIterator) Next(items interface{}) bool {
+    if iterator.err != nil || iterator.started && iterator.nextUrl == nil {
+        return false
+    }
+
+    var response *http.Response
+    var err error
+
+    if iterator.started {
+        response, err = iterator.collection.client.doUrl(iterator.ctx, "GET", iterator.nextUrl.String(), Json, nil, iterator.collection.headers...)
+    } else {
+        collection := iterator.collection
+        if iterator.pageSize > 0 {
+            collection = collection.WithParam("limit", strconv.Itoa(iterator.pageSize)).(*_collection)
+        }
+        response, err = collection.do(iterator.ctx, "GET", collection.path, Json, nil)
+    }
+
+    iterator.started = true
+    iterator.nextUrl = nil
+
+    if err != nil || response == nil {
+        iterator.err = err
+        return false
+    }
+    defer response.Body.Close()
+
+    if totalHeader := response.Header.Get(TotalCountHeader); totalHeader != "" {
+        if total, err := strconv.Atoi(totalHeader); err == nil {
+            iterator.total = total
+            iterator.hasTotal = true
+        }
+    }
+
+    if next := findLink(response.Header.Get("Link"), "next"); next != "" {
+        if nextUrl, err := url.Parse(next); err == nil {
+            iterator.nextUrl = response.Request.URL.ResolveReference(nextUrl)
+        }
+    }
+
+    itemsJson, err := ioutil.ReadAll(response.Body)
+    if err == nil {
+        err = json.Unmarshal(itemsJson, items)
+    }
+
+    if err != nil {
+        iterator.err = err
+        return false
+    }
+
+    return true
+}
+
+func (iterator *PageIterator) Err() error {
+    return iterator.err
+}
+
+func (iterator *PageIterator) Total() (int, bool) {
+    return iterator.total, iterator.hasTotal
+}
+
+func findLink(linkHeader, rel string) string {
+    for _, link := range strings.Split(linkHeader, ",") {
+        parts := strings.Split(link, ";")
+        target := strings.TrimSpace(parts[0])
+        if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+            continue
+        }
+        for _, param := range parts[1:] {
+            if name, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && strings.TrimSpace(name) == "rel" {
+                for _, linkRel := range strings.Fields(strings.Trim(strings.TrimSpace(value), "\"")) {
+                    if linkRel == rel {
+                        return target[1:len(target) - 1]
+                    }
+                }
+            }
+        }
+    }
+    return ""
+}
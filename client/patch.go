@@ -0,0 +1,60 @@
+package rest_client
+
+import (
+    "context"
+    "encoding/json"
+)
+
+const (
+    MergePatch = "application/merge-patch+json"
+    JsonPatch  = "application/json-patch+json"
+)
+
+type PatchOperation struct {
+    Op    string      `json:"op"`
+    Path  string      `json:"path"`
+    From  string      `json:"from,omitempty"`
+    Value interface{} `json:"value,omitempty"`
+}
+
+func (operation *PatchOperation) MarshalJSON() ([]byte, error) {
+    type plainOperation PatchOperation
+    if operation.Op != "add" && operation.Op != "replace" && operation.Op != "test" {
+        return json.Marshal((*plainOperation)(operation))
+    }
+    return json.Marshal(&struct {
+        *plainOperation
+        Value interface{} `json:"value"`
+    }{
+        plainOperation: (*plainOperation)(operation),
+        Value:          operation.Value,
+    })
+}
+
+func (collection *_collection) MergePatch(id string, patch interface{}) error {
+    return collection.MergePatchCtx(context.Background(), id, patch)
+}
+
+func (collection *_collection) MergePatchCtx(ctx context.Context, id string, patch interface{}) error {
+    patchJson, err := json.Marshal(patch)
+    if err != nil {
+        return err
+    }
+    return collection.doPatch(ctx, id, MergePatch, patchJson)
+}
+
+func (collection *_collection) JSONPatch(id string, operations... *PatchOperation) error {
+    return collection.JSONPatchCtx(context.Background(), id, operations...)
+}
+
+func (collection *_collection) JSONPatchCtx(ctx context.Context, id string, operations... *PatchOperation) error {
+    patchJson, err := json.Marshal(operations)
+    if err != nil {
+        return err
+    }
+    return collection.doPatch(ctx, id, JsonPatch, patchJson)
+}
+
+func (collection *_collection) doPatch(ctx context.Context, id, contentType string, patchContent []byte) error {
+    return CloseResponse(collection.do(ctx, "PATCH", collection.itemPath(id), contentType, patchContent))
+}
@@ -0,0 +1,346 @@
+package rest_client
+
+import (
+    "context"
+    "errors"
+    "io"
+    "io/ioutil"
+    "math"
+    "math/rand"
+    "net"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "sync"
+    "syscall"
+    "time"
+)
+
+const (
+    defaultInitialDelay = 100 * time.Millisecond
+    defaultMaxDelay     = 10 * time.Second
+    defaultMultiplier   = 2.0
+)
+
+var (
+    ErrCircuitOpen = errors.New("Circuit breaker is open")
+
+    defaultRetryStatuses = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+)
+
+// RetryPolicy describes how failed requests are retried; MaxAttempts includes the first attempt.
+// Non-idempotent methods are only retried when the request carries an Idempotency-Key header.
+type RetryPolicy struct {
+    MaxAttempts   int
+    InitialDelay  time.Duration
+    MaxDelay      time.Duration
+    Multiplier    float64
+    Jitter        float64
+    RetryStatuses []int
+}
+
+// CircuitBreakerPolicy opens a host's circuit after FailureThreshold consecutive failures
+// and lets a single trial request through once OpenTimeout has passed.
+type CircuitBreakerPolicy struct {
+    FailureThreshold int
+    OpenTimeout      time.Duration
+}
+
+func (client *Client) SetRetryPolicy(policy *RetryPolicy) *Client {
+    client.retryPolicy = policy
+    return client
+}
+
+func (client *Client) SetCircuitBreaker(policy *CircuitBreakerPolicy) *Client {
+    if policy == nil {
+        client.breakers = nil
+    } else {
+        client.breakers = &circuitBreakers{
+            policy: *policy,
+            hosts:  make(map[string]*circuitBreaker),
+        }
+    }
+    return client
+}
+
+func (client *Client) maxAttempts(method string, contentReader io.Reader, additionalHeaders []*Header) int {
+    policy := client.retryPolicy
+    if policy == nil || policy.MaxAttempts <= 1 {
+        return 1
+    }
+
+    if !isIdempotent(method) && !hasHeader(additionalHeaders, IdempotencyKeyHeader) {
+        return 1
+    }
+
+    if contentReader != nil {
+        if _, ok := contentReader.(io.Seeker); !ok {
+            return 1
+        }
+    }
+
+    return policy.MaxAttempts
+}
+
+func isIdempotent(method string) bool {
+    switch strings.ToUpper(method) {
+    case "GET", "HEAD", "OPTIONS", "TRACE", "PUT", "DELETE":
+        return true
+    default:
+        return false
+    }
+}
+
+func hasHeader(headers []*Header, name string) bool {
+    for _, header := range headers {
+        if header != nil && strings.EqualFold(header.Name, name) && len(header.Values) != 0 {
+            return true
+        }
+    }
+    return false
+}
+
+func (policy *RetryPolicy) shouldRetry(ctx context.Context, response *http.Response, err error) bool {
+    if ctx.Err() != nil || err == ErrCircuitOpen {
+        return false
+    }
+
+    if err != nil {
+        return transientError(err)
+    }
+
+    retryStatuses := policy.RetryStatuses
+    if retryStatuses == nil {
+        retryStatuses = defaultRetryStatuses
+    }
+
+    for _, status := range retryStatuses {
+        if response.StatusCode == status {
+            return true
+        }
+    }
+
+    return false
+}
+
+// transientError tells network failures, which may pass on the next attempt, from errors that would only repeat:
+// invalid requests, interceptor or token source failures and cancellation.
+func transientError(err error) bool {
+    if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+        return false
+    }
+
+    // url.Error wraps everything http.Client returns and pretends to be a net.Error itself
+    var urlErr *url.Error
+    if errors.As(err, &urlErr) {
+        err = urlErr.Err
+    }
+
+    if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) {
+        return true
+    }
+
+    var netErr net.Error
+    return errors.As(err, &netErr)
+}
+
+// delay returns the pause before the next attempt, or false when the server asks to wait longer than MaxDelay.
+func (policy *RetryPolicy) delay(attempt int, response *http.Response) (time.Duration, bool) {
+    initialDelay, maxDelay, multiplier := policy.InitialDelay, policy.MaxDelay, policy.Multiplier
+    if initialDelay <= 0 {
+        initialDelay = defaultInitialDelay
+    }
+    if maxDelay <= 0 {
+        maxDelay = defaultMaxDelay
+    }
+    if multiplier < 1 {
+        multiplier = defaultMultiplier
+    }
+
+    delay := time.Duration(float64(initialDelay) * math.Pow(multiplier, float64(attempt - 1)))
+    if delay > maxDelay || delay <= 0 {
+        delay = maxDelay
+    }
+
+    if policy.Jitter > 0 {
+        delay += time.Duration((rand.Float64() * 2 - 1) * policy.Jitter * float64(delay))
+    }
+
+    if response != nil {
+        if retryAfter, ok := parseRetryAfter(response.Header.Get("Retry-After")); ok {
+            if retryAfter > maxDelay {
+                return 0, false
+            }
+            if retryAfter > delay {
+                delay = retryAfter
+            }
+        }
+    }
+
+    return delay, true
+}
+
+func parseRetryAfter(retryAfter string) (time.Duration, bool) {
+    retryAfter = strings.TrimSpace(retryAfter)
+    if retryAfter == "" {
+        return 0, false
+    }
+
+    if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+        return time.Duration(seconds) * time.Second, true
+    }
+
+    if date, err := http.ParseTime(retryAfter); err == nil {
+        if delay := time.Until(date); delay > 0 {
+            return delay, true
+        }
+        return 0, true
+    }
+
+    return 0, false
+}
+
+func sleep(ctx context.Context, delay time.Duration) error {
+    timer := time.NewTimer(delay)
+    defer timer.Stop()
+
+    select {
+    case <-timer.C:
+        return nil
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
+
+func discard(response *http.Response) {
+    if response != nil {
+        io.Copy(ioutil.Discard, response.Body)
+        response.Body.Close()
+    }
+}
+
+// rewindableBody keeps the transport from closing a seekable body between attempts.
+type rewindableBody struct {
+    io.ReadSeeker
+
+    start  int64
+    length int64
+}
+
+func newRewindableBody(reader io.Reader) (*rewindableBody, error) {
+    seeker := reader.(io.ReadSeeker)
+
+    start, err := seeker.Seek(0, io.SeekCurrent)
+    if err != nil {
+        return nil, err
+    }
+
+    end, err := seeker.Seek(0, io.SeekEnd)
+    if err != nil {
+        return nil, err
+    }
+
+    body := &rewindableBody{
+        ReadSeeker: seeker,
+        start:      start,
+        length:     end - start,
+    }
+
+    return body, body.rewind()
+}
+
+func (body *rewindableBody) rewind() error {
+    _, err := body.Seek(body.start, io.SeekStart)
+    return err
+}
+
+type circuitBreakers struct {
+    policy CircuitBreakerPolicy
+    mutex  sync.Mutex
+    hosts  map[string]*circuitBreaker
+}
+
+type circuitBreaker struct {
+    failures  int
+    openUntil time.Time
+    trial     bool
+}
+
+func (breakers *circuitBreakers) allow(requestUrl string) bool {
+    if breakers == nil {
+        return true
+    }
+
+    breakers.mutex.Lock()
+    defer breakers.mutex.Unlock()
+
+    breaker := breakers.hosts[hostOf(requestUrl)]
+    if breaker == nil || breaker.openUntil.IsZero() {
+        return true
+    }
+
+    if breaker.trial || time.Now().Before(breaker.openUntil) {
+        return false
+    }
+
+    breaker.trial = true
+
+    return true
+}
+
+func (breakers *circuitBreakers) record(requestUrl string, failed bool) {
+    if breakers == nil {
+        return
+    }
+
+    breakers.mutex.Lock()
+    defer breakers.mutex.Unlock()
+
+    host := hostOf(requestUrl)
+
+    breaker := breakers.hosts[host]
+    if breaker == nil {
+        breaker = &circuitBreaker{}
+        breakers.hosts[host] = breaker
+    }
+
+    breaker.trial = false
+
+    if !failed {
+        breaker.failures = 0
+        breaker.openUntil = time.Time{}
+        return
+    }
+
+    breaker.failures++
+
+    threshold := breakers.policy.FailureThreshold
+    if threshold <= 0 {
+        threshold = 1
+    }
+
+    if breaker.failures >= threshold {
+        breaker.openUntil = time.Now().Add(breakers.policy.OpenTimeout)
+    }
+}
+
+func (breakers *circuitBreakers) release(requestUrl string) {
+    if breakers == nil {
+        return
+    }
+
+    breakers.mutex.Lock()
+    defer breakers.mutex.Unlock()
+
+    if breaker := breakers.hosts[hostOf(requestUrl)]; breaker != nil {
+        breaker.trial = false
+    }
+}
+
+func hostOf(requestUrl string) string {
+    if parsedUrl, err := url.Parse(requestUrl); err == nil {
+        return parsedUrl.Host
+    }
+    return requestUrl
+}
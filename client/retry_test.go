@@ -0,0 +1,66 @@
+package rest_client
+
+import (
+    "errors"
+    "net"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+)
+
+func countingInterceptor(attempts *int, err error) Interceptor {
+    return func(next RoundTrip) RoundTrip {
+        return func(request *http.Request) (*http.Response, error) {
+            *attempts++
+            if err != nil {
+                return nil, err
+            }
+            return next(request)
+        }
+    }
+}
+
+func TestRetryOnlyTransientFailures(t *testing.T) {
+    failing := true
+    server := httptest.NewServer(http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+        if failing {
+            failing = false
+            response.WriteHeader(http.StatusServiceUnavailable)
+        }
+    }))
+    defer server.Close()
+
+    // nothing listens there once the listener is closed
+    listener, err := net.Listen("tcp", "127.0.0.1:0")
+    if err != nil {
+        t.Fatal(err)
+    }
+    refusedUrl := "http://" + listener.Addr().String()
+    listener.Close()
+
+    policy := &RetryPolicy{MaxAttempts: 3, InitialDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+    tests := []struct {
+        name     string
+        url      string
+        err      error
+        attempts int
+    }{
+        {name: "retryable status", url: server.URL, attempts: 2},
+        {name: "connection refused", url: refusedUrl, attempts: 3},
+        {name: "interceptor error", url: server.URL, err: errors.New("no token"), attempts: 1},
+        {name: "invalid url", url: "unknown://host", attempts: 1},
+    }
+
+    for _, test := range tests {
+        attempts := 0
+        client := New(test.url, http.DefaultClient).SetRetryPolicy(policy).Use(countingInterceptor(&attempts, test.err))
+
+        client.Do("GET", "/items", "", nil)
+
+        if attempts != test.attempts {
+            t.Errorf("%s: expected %d attempts, got %d", test.name, test.attempts, attempts)
+        }
+    }
+}
@@ -0,0 +1,95 @@
+package rest_client
+
+import (
+    "context"
+)
+
+type TypedCollection[T any] struct {
+    collection Collection
+}
+
+func Typed[T any](collection Collection) *TypedCollection[T] {
+    return &TypedCollection[T]{
+        collection: collection,
+    }
+}
+
+func (collection *TypedCollection[T]) Untyped() Collection {
+    return collection.collection
+}
+
+func (collection *TypedCollection[T]) List() ([]*T, error) {
+    return collection.ListCtx(context.Background())
+}
+
+func (collection *TypedCollection[T]) ListCtx(ctx context.Context) ([]*T, error) {
+    items := make([]*T, 0)
+    if err := collection.collection.ListCtx(ctx, &items); err != nil {
+        return nil, err
+    }
+    return items, nil
+}
+
+func (collection *TypedCollection[T]) ListEach(each func(item *T) error) error {
+    return collection.ListEachCtx(context.Background(), each)
+}
+
+func (collection *TypedCollection[T]) ListEachCtx(ctx context.Context, each func(item *T) error) error {
+    return collection.collection.ListEachCtx(ctx, func() interface{} {
+        return new(T)
+    }, func(item interface{}) error {
+        return each(item.(*T))
+    })
+}
+
+func (collection *TypedCollection[T]) Get(id string) (*T, error) {
+    return collection.GetCtx(context.Background(), id)
+}
+
+func (collection *TypedCollection[T]) GetCtx(ctx context.Context, id string) (*T, error) {
+    var item *T
+    if err := collection.collection.GetCtx(ctx, id, &item); err != nil {
+        return nil, err
+    }
+    return item, nil
+}
+
+func (collection *TypedCollection[T]) Exists(id string) (bool, error) {
+    return collection.collection.Exists(id)
+}
+
+func (collection *TypedCollection[T]) ExistsCtx(ctx context.Context, id string) (bool, error) {
+    return collection.collection.ExistsCtx(ctx, id)
+}
+
+func (collection *TypedCollection[T]) Create(item *T) (string, error) {
+    return collection.CreateCtx(context.Background(), item)
+}
+
+func (collection *TypedCollection[T]) CreateCtx(ctx context.Context, item *T) (string, error) {
+    return collection.collection.CreateCtx(ctx, item)
+}
+
+func (collection *TypedCollection[T]) Update(id string, item *T) error {
+    return collection.UpdateCtx(context.Background(), id, item)
+}
+
+func (collection *TypedCollection[T]) UpdateCtx(ctx context.Context, id string, item *T) error {
+    return collection.collection.UpdateCtx(ctx, id, item)
+}
+
+func (collection *TypedCollection[T]) Replace(id string, item *T) error {
+    return collection.ReplaceCtx(context.Background(), id, item)
+}
+
+func (collection *TypedCollection[T]) ReplaceCtx(ctx context.Context, id string, item *T) error {
+    return collection.collection.ReplaceCtx(ctx, id, item)
+}
+
+func (collection *TypedCollection[T]) Delete(id string) error {
+    return collection.collection.Delete(id)
+}
+
+func (collection *TypedCollection[T]) DeleteCtx(ctx context.Context, id string) error {
+    return collection.collection.DeleteCtx(ctx, id)
+}
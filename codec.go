@@ -0,0 +1,151 @@
+package rest
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "github.com/ghodss/yaml"
+    "github.com/maxmanuylov/go-rest/error"
+    "mime"
+    "net/http"
+    "strings"
+)
+
+const (
+    JsonContentType = "application/json"
+    YamlContentType = "application/yaml"
+)
+
+var (
+    ErrNotAcceptable        = rest_error.NewByCode(http.StatusNotAcceptable)
+    ErrUnsupportedMediaType = rest_error.NewByCode(http.StatusUnsupportedMediaType)
+)
+
+type Codec interface {
+    Marshal(v interface{}, indent string) ([]byte, error)
+    Unmarshal(data []byte, v interface{}) error
+}
+
+type JsonCodec struct{}
+
+func (JsonCodec) Marshal(v interface{}, indent string) ([]byte, error) {
+    if indent == "" {
+        return json.Marshal(v)
+    }
+    return json.MarshalIndent(v, "", indent)
+}
+
+func (JsonCodec) Unmarshal(data []byte, v interface{}) error {
+    return json.Unmarshal(data, v)
+}
+
+type YamlCodec struct{}
+
+func (YamlCodec) Marshal(v interface{}, _ string) ([]byte, error) {
+    return yaml.Marshal(v)
+}
+
+func (YamlCodec) Unmarshal(data []byte, v interface{}) error {
+    return yaml.Unmarshal(data, v)
+}
+
+type codecEntry struct {
+    contentType string
+    codec       Codec
+}
+
+type codecRegistry struct {
+    entries []*codecEntry
+}
+
+func newCodecRegistry() *codecRegistry {
+    registry := &codecRegistry{}
+    registry.register(JsonCodec{}, JsonContentType)
+    registry.register(YamlCodec{}, YamlContentType, "application/x-yaml", "text/yaml")
+    return registry
+}
+
+var defaultCodecs = newCodecRegistry()
+
+func (server *Server) RegisterCodec(codec Codec, contentTypes... string) *Server {
+    server.settings.codecs.register(codec, contentTypes...)
+    return server
+}
+
+func (registry *codecRegistry) register(codec Codec, contentTypes... string) {
+    for _, contentType := range contentTypes {
+        contentType = strings.ToLower(strings.TrimSpace(contentType))
+        if entry := registry.find(contentType); entry != nil {
+            entry.codec = codec
+        } else {
+            registry.entries = append(registry.entries, &codecEntry{
+                contentType: contentType,
+                codec:       codec,
+            })
+        }
+    }
+}
+
+func (registry *codecRegistry) find(contentType string) *codecEntry {
+    for _, entry := range registry.entries {
+        if entry.contentType == contentType {
+            return entry
+        }
+    }
+    return nil
+}
+
+func (registry *codecRegistry) decoder(contentTypeHeader string) (Codec, error) {
+    if strings.TrimSpace(contentTypeHeader) == "" {
+        return registry.entries[0].codec, nil
+    }
+
+    mediaType, _, err := mime.ParseMediaType(contentTypeHeader)
+    if err != nil {
+        return nil, rest_error.New(http.StatusUnsupportedMediaType, fmt.Sprintf("Invalid Content-Type: %s", contentTypeHeader))
+    }
+
+    if entry := registry.find(mediaType); entry != nil {
+        return entry.codec, nil
+    }
+
+    return nil, rest_error.New(http.StatusUnsupportedMediaType, fmt.Sprintf("Unsupported Content-Type: %s", mediaType))
+}
+
+func (registry *codecRegistry) encoder(acceptHeader string) (string, Codec, error) {
+    if strings.TrimSpace(acceptHeader) == "" {
+        return registry.entries[0].contentType, registry.entries[0].codec, nil
+    }
+
+    for _, mediaRange := range rest_error.AcceptedRanges(acceptHeader) {
+        if mediaRange == "*/*" {
+            return registry.entries[0].contentType, registry.entries[0].codec, nil
+        }
+        for _, entry := range registry.entries {
+            if entry.contentType == mediaRange || strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(entry.contentType, strings.TrimSuffix(mediaRange, "*")) {
+                return entry.contentType, entry.codec, nil
+            }
+        }
+    }
+
+    return "", nil, rest_error.New(http.StatusNotAcceptable, fmt.Sprintf("None of the accepted types is supported: %s", acceptHeader))
+}
+
+func (r *Request) codecs() *codecRegistry {
+    if r.server == nil {
+        return defaultCodecs
+    }
+    return r.server.settings.codecs
+}
+
+func (r *Request) ResponseCodec() (string, Codec, error) {
+    return r.codecs().encoder(r.Header.Get("Accept"))
+}
+
+func (r *Request) RequestCodec() (Codec, error) {
+    return r.codecs().decoder(r.Header.Get("Content-Type"))
+}
+
+func (r *Request) Unmarshal(data []byte, v interface{}) error {
+    return r.decode(bytes.NewReader(data), v)
+}
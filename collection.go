@@ -4,6 +4,7 @@ import (
     "fmt"
     "github.com/maxmanuylov/go-rest/error"
     "net/http"
+    "sort"
     "strings"
 )
 
@@ -16,6 +17,12 @@ type Request struct {
 
     Level int
     IDs   []string
+
+    server         *Server
+    params         map[string]string
+    listOptions    *ListOptions
+    deleteSelector *DeleteSelector
+    principal      interface{}
 }
 
 type Handler interface {
@@ -28,21 +35,45 @@ func (f HandlerFunc) ServeHTTP(request *Request, response http.ResponseWriter) {
     f(request, response)
 }
 
+// Middlewares run outermost first: root server, prefixed servers, root collection down to
+// the target collection; within each of them in the order they were added by Use.
+// Authentication and authorization run inside the chain, so middlewares see their 401 and 403
+// responses but not the principal: Request.Principal is set only after next is called.
+type Middleware func(next Handler) Handler
+
 type Collection struct {
+    name           string
+    path           string
+    idName         string
+    idType         *IDType
     level          int
     handler        Handler
     subCollections map[string]*Collection
+    parent         *Collection
+    server         *Server
+    middlewares    []Middleware
+    corsPolicy     *CORSPolicy
+    authorizer     Authorizer
+    maxBodySize    int64
 }
 
-func newCollection(level int) *Collection {
+func newCollection(server *Server, parent *Collection, name string) *Collection {
+    level := 0
+    if parent != nil {
+        level = parent.level + 1
+    }
+
     return &Collection{
+        name:           name,
         level:          level,
         subCollections: make(map[string]*Collection),
+        parent:         parent,
+        server:         server,
     }
 }
 
 func (server *Server) Collection(name string) *Collection {
-    collection := newCollection(0)
+    collection := newCollection(server, nil, name)
 
     if strings.Contains(name, "/") {
         panic(fmt.Sprintf("Slash in collection name: %s", name))
@@ -55,26 +86,43 @@ func (server *Server) Collection(name string) *Collection {
     collectionPath := server.path(fmt.Sprintf("/%s", name))
     collectionIndex := len(splitPath(collectionPath)) - 1
 
-    handlerFunc := func(response http.ResponseWriter, httpRequest *http.Request) {
+    collection.path = collectionPath
+    server.settings.collections = append(server.settings.collections, collection)
+
+    handlerFunc := func(httpResponse http.ResponseWriter, httpRequest *http.Request) {
+        request := &Request{
+            Request: httpRequest,
+            server:  server,
+        }
+        response := &trackingResponseWriter{ResponseWriter: httpResponse}
+
+        defer server.recoverPanic(request, response)
+
         pathNames := splitPath(httpRequest.URL.Path)[collectionIndex:]
 
         if len(pathNames) == 0 { // cannot happen
-            writeError(response, fmt.Errorf("Invalid URL path: %s", httpRequest.URL.Path))
+            writeError(response, httpRequest, fmt.Errorf("Invalid URL path: %s", httpRequest.URL.Path))
             return
         }
 
         ids := make([]string, 0)
+        params := make(map[string]string)
         actualCollection := collection
         id := true
 
         for i, pathName := range pathNames[1:] {
             if id {
+                if err := actualCollection.checkID(pathName); err != nil {
+                    writeError(response, httpRequest, err)
+                    return
+                }
                 ids = append(ids, pathName)
+                params[actualCollection.idParamName()] = pathName
                 id = false
             } else {
                 actualCollection = actualCollection.subCollections[pathName]
                 if actualCollection == nil {
-                    writeError(response, rest_error.New(http.StatusNotFound, fmt.Sprintf("Path is not found: /%s", strings.Join(pathNames[:i + 2], "/"))))
+                    writeError(response, httpRequest, rest_error.New(http.StatusNotFound, fmt.Sprintf("Path is not found: /%s", strings.Join(pathNames[:i + 2], "/"))))
                     return
                 }
                 id = true
@@ -82,17 +130,25 @@ func (server *Server) Collection(name string) *Collection {
         }
 
         if actualCollection.handler == nil {
-            writeError(response, ErrMethodNotAllowed)
+            writeError(response, httpRequest, rest_error.New(http.StatusNotFound, fmt.Sprintf("Path is not found: %s", httpRequest.URL.Path)))
             return
         }
 
-        request := &Request{
-            Request: httpRequest,
-            Level:   actualCollection.level,
-            IDs:     ids,
+        request.Level = actualCollection.level
+        request.IDs = ids
+        request.params = params
+
+        if policy := actualCollection.effectiveCORSPolicy(); policy != nil {
+            if policy.handleCORS(httpRequest, response, actualCollection.routeMethods(request.Level == len(ids))) {
+                return
+            }
+        }
+
+        if !actualCollection.limitBody(request, response) {
+            return
         }
 
-        actualCollection.handler.ServeHTTP(request, response)
+        actualCollection.chain(server.authenticating(actualCollection.authorizing(actualCollection.handler))).ServeHTTP(request, response)
     }
 
     server.mux.HandleFunc(collectionPath, handlerFunc)
@@ -110,29 +166,52 @@ func (collection *Collection) CustomHandlerFunc(handlerFunc func(*Request, http.
     return collection.CustomHandler(HandlerFunc(handlerFunc))
 }
 
+func (collection *Collection) Use(middlewares... Middleware) *Collection {
+    collection.middlewares = append(collection.middlewares, middlewares...)
+    return collection
+}
+
+func (collection *Collection) chain(handler Handler) Handler {
+    for current := collection; current != nil; current = current.parent {
+        handler = wrap(handler, current.middlewares)
+    }
+    return collection.server.chain(handler)
+}
+
+func wrap(handler Handler, middlewares []Middleware) Handler {
+    for i := len(middlewares) - 1; i >= 0; i-- {
+        handler = middlewares[i](handler)
+    }
+    return handler
+}
+
 func (collection *Collection) SubCollection(name string) *Collection {
-    subCollection := newCollection(collection.level + 1)
+    subCollection := newCollection(collection.server, collection, name)
+    subCollection.checkIDParamName(subCollection.idParamName())
     collection.subCollections[name] = subCollection
     return subCollection
 }
 
+func (collection *Collection) sortedSubCollections() []*Collection {
+    names := make([]string, 0, len(collection.subCollections))
+    for name := range collection.subCollections {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    subCollections := make([]*Collection, len(names))
+    for i, name := range names {
+        subCollections[i] = collection.subCollections[name]
+    }
+    return subCollections
+}
+
 func splitPath(path string) []string {
     return strings.FieldsFunc(strings.TrimSpace(path), func(r rune) bool {
         return r == '/'
     })
 }
 
-func writeError(response http.ResponseWriter, err error) {
-    var code int
-    var message string
-
-    if restError, ok := err.(*rest_error.Error); ok {
-        code = restError.Code
-        message = restError.Message
-    } else {
-        code = http.StatusInternalServerError
-        message = err.Error()
-    }
-
-    http.Error(response, message, code)
+func writeError(response http.ResponseWriter, request *http.Request, err error) {
+    rest_error.Reply(err, request, response)
 }
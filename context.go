@@ -0,0 +1,86 @@
+package rest
+
+import (
+    "context"
+    "strings"
+)
+
+type ResourceHandlerCtx interface {
+    EmptyItem() interface{}
+    Create(ctx context.Context, request *Request, item interface{}) (string, error)
+    Read(ctx context.Context, request *Request) (interface{}, error)
+    List(ctx context.Context, request *Request) (interface{}, error)
+    Update(ctx context.Context, request *Request, item interface{}) error
+    Replace(ctx context.Context, request *Request, item interface{}) error
+    Delete(ctx context.Context, request *Request) error
+    BatchDelete(ctx context.Context, request *Request) error
+}
+
+type ActionHandlerCtx interface {
+    Do(ctx context.Context, request *Request) error
+}
+
+func (collection *Collection) HandlerCtx(handler ResourceHandlerCtx) *ResourceCollection {
+    resourceCollection := collection.Handler(&ctxResourceHandler{handler})
+    resourceCollection.resourceHandler.extensions = handler
+    return resourceCollection
+}
+
+func (collection *ResourceCollection) CustomActionCtx(method string, handler ActionHandlerCtx) *ResourceCollection {
+    collection.resourceHandler.customActions[strings.ToUpper(method)] = &ctxActionHandler{handler}
+    return collection
+}
+
+func (r *Request) WithContext(ctx context.Context) *Request {
+    newRequest := *r
+    newRequest.Request = r.Request.WithContext(ctx)
+    return &newRequest
+}
+
+/* *** */
+
+type ctxResourceHandler struct {
+    handler ResourceHandlerCtx
+}
+
+func (h *ctxResourceHandler) EmptyItem() interface{} {
+    return h.handler.EmptyItem()
+}
+
+func (h *ctxResourceHandler) Create(request *Request, item interface{}) (string, error) {
+    return h.handler.Create(request.Context(), request, item)
+}
+
+func (h *ctxResourceHandler) Read(request *Request) (interface{}, error) {
+    return h.handler.Read(request.Context(), request)
+}
+
+func (h *ctxResourceHandler) List(request *Request) (interface{}, error) {
+    return h.handler.List(request.Context(), request)
+}
+
+func (h *ctxResourceHandler) Update(request *Request, item interface{}) error {
+    return h.handler.Update(request.Context(), request, item)
+}
+
+func (h *ctxResourceHandler) Replace(request *Request, item interface{}) error {
+    return h.handler.Replace(request.Context(), request, item)
+}
+
+func (h *ctxResourceHandler) Delete(request *Request) error {
+    return h.handler.Delete(request.Context(), request)
+}
+
+func (h *ctxResourceHandler) BatchDelete(request *Request) error {
+    return h.handler.BatchDelete(request.Context(), request)
+}
+
+/* *** */
+
+type ctxActionHandler struct {
+    handler ActionHandlerCtx
+}
+
+func (h *ctxActionHandler) Do(request *Request) error {
+    return h.handler.Do(request.Context(), request)
+}
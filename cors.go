@@ -0,0 +1,140 @@
+package rest
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+)
+
+type CORSPolicy struct {
+    AllowedOrigins   []string
+    AllowedMethods   []string
+    AllowedHeaders   []string
+    ExposedHeaders   []string
+    AllowCredentials bool
+    MaxAge           time.Duration
+}
+
+func (server *Server) CORS(policy *CORSPolicy) *Server {
+    policy.check()
+    server.corsPolicy = policy
+    return server
+}
+
+func (collection *Collection) CORS(policy *CORSPolicy) *Collection {
+    policy.check()
+    collection.corsPolicy = policy
+    return collection
+}
+
+func (policy *CORSPolicy) check() {
+    if policy != nil && policy.AllowCredentials && contains(policy.AllowedOrigins, "*") {
+        panic("CORS policy cannot allow credentials for any origin")
+    }
+}
+
+func (collection *Collection) effectiveCORSPolicy() *CORSPolicy {
+    for current := collection; current != nil; current = current.parent {
+        if current.corsPolicy != nil {
+            return current.corsPolicy
+        }
+    }
+    for current := collection.server; current != nil; current = current.parent {
+        if current.corsPolicy != nil {
+            return current.corsPolicy
+        }
+    }
+    return nil
+}
+
+func (collection *Collection) routeMethods(collectionRequest bool) []string {
+    if adapter, ok := collection.handler.(*resourceHandlerAdapter); ok {
+        return adapter.allowedMethods(collectionRequest)
+    }
+    return nil
+}
+
+// handleCORS adds CORS headers to the response and reports whether the request was a preflight one
+// and has already been answered.
+func (policy *CORSPolicy) handleCORS(request *http.Request, response http.ResponseWriter, routeMethods []string) bool {
+    origin := request.Header.Get("Origin")
+    if origin == "" {
+        return false
+    }
+
+    header := response.Header()
+    header.Add("Vary", "Origin")
+
+    preflight := request.Method == "OPTIONS" && request.Header.Get("Access-Control-Request-Method") != ""
+
+    if !policy.allowsOrigin(origin) {
+        if preflight {
+            writeAnswer(response, http.StatusNoContent, "", nil)
+        }
+        return preflight
+    }
+
+    if contains(policy.AllowedOrigins, "*") {
+        header.Set("Access-Control-Allow-Origin", "*")
+    } else {
+        header.Set("Access-Control-Allow-Origin", origin)
+        if policy.AllowCredentials {
+            header.Set("Access-Control-Allow-Credentials", "true")
+        }
+    }
+
+    if !preflight {
+        if len(policy.ExposedHeaders) != 0 {
+            header.Set("Access-Control-Expose-Headers", strings.Join(policy.ExposedHeaders, ", "))
+        }
+        return false
+    }
+
+    header.Add("Vary", "Access-Control-Request-Method")
+    header.Add("Vary", "Access-Control-Request-Headers")
+
+    methods := policy.allowedMethods(routeMethods)
+    if containsIgnoreCase(methods, request.Header.Get("Access-Control-Request-Method")) {
+        header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+
+        if len(policy.AllowedHeaders) != 0 {
+            header.Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+        } else if requestHeaders := request.Header.Get("Access-Control-Request-Headers"); requestHeaders != "" {
+            header.Set("Access-Control-Allow-Headers", requestHeaders)
+        }
+
+        if policy.MaxAge > 0 {
+            header.Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge / time.Second)))
+        }
+    }
+
+    writeAnswer(response, http.StatusNoContent, "", nil)
+    return true
+}
+
+func (policy *CORSPolicy) allowsOrigin(origin string) bool {
+    for _, allowed := range policy.AllowedOrigins {
+        if allowed == "*" || strings.EqualFold(allowed, origin) {
+            return true
+        }
+    }
+    return false
+}
+
+func (policy *CORSPolicy) allowedMethods(routeMethods []string) []string {
+    if len(policy.AllowedMethods) == 0 {
+        return routeMethods
+    }
+    if routeMethods == nil {
+        return policy.AllowedMethods
+    }
+
+    methods := make([]string, 0, len(routeMethods))
+    for _, method := range routeMethods {
+        if containsIgnoreCase(policy.AllowedMethods, method) {
+            methods = append(methods, method)
+        }
+    }
+    return methods
+}
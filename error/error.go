@@ -1,13 +1,40 @@
 package rest_error
 
 import (
+    "encoding/json"
     "fmt"
+    "mime"
     "net/http"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+const (
+    RequestIdHeader = "X-Request-Id"
+
+    ReasonMalformedBody = "malformed_body"
+    ReasonValidation    = "validation"
+
+    jsonContentType = "application/json"
 )
 
 type Error struct {
-    Code    int
-    Message string
+    Code      int           `json:"code"`
+    Message   string        `json:"message,omitempty"`
+    Reason    string        `json:"reason,omitempty"`
+    Fields    []*FieldError `json:"fields,omitempty"`
+    RequestId string        `json:"requestId,omitempty"`
+}
+
+type FieldError struct {
+    Path    string `json:"path"`
+    Reason  string `json:"reason,omitempty"`
+    Message string `json:"message,omitempty"`
+}
+
+type envelope struct {
+    Error *Error `json:"error"`
 }
 
 func (err *Error) Error() string {
@@ -38,14 +65,166 @@ func New(code int, message string) *Error {
     }
 }
 
+func NewWithReason(code int, reason, message string) *Error {
+    return &Error{
+        Code:    code,
+        Message: message,
+        Reason:  reason,
+    }
+}
+
+func (err *Error) WithFields(fields... *FieldError) *Error {
+    newErr := *err
+    newErr.Fields = append(append([]*FieldError{}, err.Fields...), fields...)
+    return &newErr
+}
+
+// Send writes the JSON envelope; use Reply to honour the Accept header of the request.
 func (err *Error) Send(response http.ResponseWriter) {
-    http.Error(response, err.Message, err.Code)
+    err.Reply(nil, response)
+}
+
+func (err *Error) Reply(request *http.Request, response http.ResponseWriter) {
+    replyErr := *err
+    if replyErr.Message == "" {
+        replyErr.Message = http.StatusText(err.Code)
+    }
+
+    if request != nil {
+        if !acceptsJson(request.Header.Get("Accept")) {
+            http.Error(response, replyErr.Message, err.Code)
+            return
+        }
+        if replyErr.RequestId == "" {
+            replyErr.RequestId = request.Header.Get(RequestIdHeader)
+        }
+    }
+    if replyErr.Reason == "" {
+        replyErr.Reason = DefaultReason(err.Code)
+    }
+
+    content, marshalErr := json.Marshal(&envelope{Error: &replyErr})
+    if marshalErr != nil {
+        http.Error(response, replyErr.Message, err.Code)
+        return
+    }
+
+    response.Header().Set("Content-Type", jsonContentType)
+    response.Header().Set("X-Content-Type-Options", "nosniff")
+    response.WriteHeader(err.Code)
+    response.Write(content)
 }
 
 func Send(err error, response http.ResponseWriter) {
+    From(err).Send(response)
+}
+
+func Reply(err error, request *http.Request, response http.ResponseWriter) {
+    From(err).Reply(request, response)
+}
+
+func From(err error) *Error {
     if restError, ok := err.(*Error); ok {
-        restError.Send(response)
-    } else {
-        http.Error(response, err.Error(), http.StatusInternalServerError)
+        return restError
+    }
+    if convertible, ok := err.(interface{ RestError() *Error }); ok {
+        return convertible.RestError()
     }
+    return New(http.StatusInternalServerError, err.Error())
+}
+
+func Decode(code int, contentType string, content []byte) (*Error, bool) {
+    if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || !isJson(mediaType) {
+        return nil, false
+    }
+
+    body := &envelope{}
+    if err := json.Unmarshal(content, body); err != nil || body.Error == nil {
+        return nil, false
+    }
+
+    if body.Error.Code == 0 {
+        body.Error.Code = code
+    }
+
+    return body.Error, true
+}
+
+func DefaultReason(code int) string {
+    return strings.Replace(strings.ToLower(http.StatusText(code)), " ", "_", -1)
+}
+
+// acceptsJson picks the quality of the most specific range matching JSON, so that an explicit
+// "application/json;q=0" wins over "*/*"; a missing Accept header means anything goes.
+func acceptsJson(accept string) bool {
+    if strings.TrimSpace(accept) == "" {
+        return true
+    }
+
+    quality, specificity := 0.0, -1
+    for _, accepted := range parseAccept(accept) {
+        rangeSpecificity := -1
+        switch {
+        case isJson(accepted.mediaRange):
+            rangeSpecificity = 2
+        case accepted.mediaRange == "application/*":
+            rangeSpecificity = 1
+        case accepted.mediaRange == "*/*":
+            rangeSpecificity = 0
+        }
+        if rangeSpecificity > specificity {
+            quality, specificity = accepted.quality, rangeSpecificity
+        }
+    }
+
+    return quality > 0
+}
+
+type acceptedRange struct {
+    mediaRange string
+    quality    float64
+}
+
+// AcceptedRanges returns the media ranges of an Accept header with a non-zero quality, most preferred first.
+func AcceptedRanges(accept string) []string {
+    mediaRanges := make([]string, 0)
+    for _, accepted := range parseAccept(accept) {
+        if accepted.quality > 0 {
+            mediaRanges = append(mediaRanges, accepted.mediaRange)
+        }
+    }
+    return mediaRanges
+}
+
+func parseAccept(accept string) []*acceptedRange {
+    ranges := make([]*acceptedRange, 0)
+
+    for _, part := range strings.Split(accept, ",") {
+        mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+        if err != nil {
+            continue
+        }
+
+        quality := 1.0
+        if q, ok := params["q"]; ok {
+            if quality, err = strconv.ParseFloat(q, 64); err != nil {
+                continue
+            }
+        }
+
+        ranges = append(ranges, &acceptedRange{
+            mediaRange: mediaRange,
+            quality:    quality,
+        })
+    }
+
+    sort.SliceStable(ranges, func(i, j int) bool {
+        return ranges[i].quality > ranges[j].quality
+    })
+
+    return ranges
+}
+
+func isJson(mediaType string) bool {
+    return mediaType == jsonContentType || strings.HasSuffix(mediaType, "+json")
 }
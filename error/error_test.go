@@ -0,0 +1,56 @@
+package rest_error
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+func TestReplyNegotiation(t *testing.T) {
+    tests := []struct {
+        accept string
+        json   bool
+    }{
+        {accept: "", json: true},
+        {accept: "*/*", json: true},
+        {accept: "application/*", json: true},
+        {accept: "application/json", json: true},
+        {accept: "application/problem+json", json: true},
+        {accept: "text/html, */*;q=0.8", json: true},
+        {accept: "text/plain", json: false},
+        {accept: "application/json;q=0", json: false},
+        {accept: "application/json;q=0, */*", json: false},
+        {accept: "application/*;q=0, application/json", json: true},
+    }
+
+    for _, test := range tests {
+        request := httptest.NewRequest("GET", "/", nil)
+        if test.accept != "" {
+            request.Header.Set("Accept", test.accept)
+        }
+
+        response := httptest.NewRecorder()
+        NewByCode(http.StatusMethodNotAllowed).Reply(request, response)
+
+        if isJson := strings.HasPrefix(response.Header().Get("Content-Type"), jsonContentType); isJson != test.json {
+            t.Errorf("%q: expected JSON %v, got %q", test.accept, test.json, response.Header().Get("Content-Type"))
+        }
+        if response.Code != http.StatusMethodNotAllowed {
+            t.Errorf("%q: unexpected status %d", test.accept, response.Code)
+        }
+        if !strings.Contains(response.Body.String(), "Method Not Allowed") {
+            t.Errorf("%q: expected a message, got %q", test.accept, response.Body.String())
+        }
+    }
+}
+
+func TestSendWritesEnvelope(t *testing.T) {
+    response := httptest.NewRecorder()
+    New(http.StatusForbidden, "only alice").Send(response)
+
+    expected := `{"error":{"code":403,"message":"only alice","reason":"forbidden"}}`
+    if response.Body.String() != expected {
+        t.Errorf("expected %s, got %s", expected, response.Body.String())
+    }
+}
@@ -0,0 +1,234 @@
+package rest
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "fmt"
+    "github.com/maxmanuylov/go-rest/error"
+    "mime"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    IdempotencyKeyHeader     = "Idempotency-Key"
+    IdempotentReplayedHeader = "Idempotent-Replayed"
+
+    ReasonIdempotencyKeyReused     = "idempotency_key_reused"
+    ReasonIdempotencyKeyInProgress = "idempotency_key_in_progress"
+)
+
+// IdempotencyRecord is the outcome of a successful create stored under its idempotency key.
+type IdempotencyRecord struct {
+    Fingerprint string
+    Status      int
+    Location    string
+    ContentType string
+    Body        []byte
+}
+
+// IdempotencyStore keeps create outcomes by key. Reserve returns the completed record for a known key
+// or claims the key for the caller by returning nil; the claim ends with either Complete or Release.
+type IdempotencyStore interface {
+    Reserve(key string) (*IdempotencyRecord, error)
+    Complete(key string, record *IdempotencyRecord) error
+    Release(key string) error
+}
+
+func (server *Server) IdempotencyStore(store IdempotencyStore) *Server {
+    server.settings.idempotencyStore = store
+    return server
+}
+
+func (resourceHandler *resourceHandlerAdapter) handleIdempotentCreate(request *Request, response http.ResponseWriter, key string, itemContent []byte) {
+    store := request.server.settings.idempotencyStore
+    key = idempotencyScope(request) + " " + key
+    fingerprint := requestFingerprint(request, itemContent)
+
+    record, err := store.Reserve(key)
+    if err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
+    if record != nil {
+        if record.Fingerprint != fingerprint {
+            writeError(response, request.Request, rest_error.NewWithReason(http.StatusUnprocessableEntity, ReasonIdempotencyKeyReused, "Idempotency key was already used with a different request body"))
+            return
+        }
+        if record.Location != "" {
+            response.Header().Set("Location", record.Location)
+        }
+        response.Header().Set(IdempotentReplayedHeader, "true")
+        writeAnswer(response, record.Status, record.ContentType, record.Body)
+        return
+    }
+
+    recorder := &recordingResponseWriter{ResponseWriter: response}
+    completed := false
+
+    defer func() {
+        if !completed {
+            store.Release(key)
+        }
+    }()
+
+    resourceHandler.create(request, recorder, itemContent)
+
+    if recorder.status / 100 == 2 {
+        err = store.Complete(key, &IdempotencyRecord{
+            Fingerprint: fingerprint,
+            Status:      recorder.status,
+            Location:    response.Header().Get("Location"),
+            ContentType: response.Header().Get("Content-Type"),
+            Body:        recorder.body.Bytes(),
+        })
+        completed = err == nil
+    }
+}
+
+// IdempotencyPrincipal is an optional principal extension giving a stable identity to scope idempotency keys by.
+// Other principals are identified by their type and Go syntax representation, so principals holding pointers
+// never match across requests and lose the replay protection.
+type IdempotencyPrincipal interface {
+    IdempotencyPrincipal() string
+}
+
+// Keys are scoped by path and principal so that one client can neither replay nor block another client's creates
+func idempotencyScope(request *Request) string {
+    principal := ""
+    switch value := request.Principal().(type) {
+    case nil:
+    case IdempotencyPrincipal:
+        principal = fmt.Sprintf("%T:%s", value, value.IdempotencyPrincipal())
+    default:
+        principal = fmt.Sprintf("%T:%#v", value, value)
+    }
+    return fmt.Sprintf("%s %q", request.URL.Path, principal)
+}
+
+func requestFingerprint(request *Request, content []byte) string {
+    mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type"))
+
+    hash := sha256.New()
+    hash.Write([]byte(strings.ToLower(mediaType)))
+    hash.Write([]byte{0})
+    hash.Write(content)
+
+    return hex.EncodeToString(hash.Sum(nil))
+}
+
+type recordingResponseWriter struct {
+    http.ResponseWriter
+
+    status int
+    body   bytes.Buffer
+}
+
+func (response *recordingResponseWriter) WriteHeader(status int) {
+    if response.status == 0 {
+        response.status = status
+    }
+    response.ResponseWriter.WriteHeader(status)
+}
+
+func (response *recordingResponseWriter) Write(content []byte) (int, error) {
+    if response.status == 0 {
+        response.status = http.StatusOK
+    }
+    response.body.Write(content)
+    return response.ResponseWriter.Write(content)
+}
+
+/* *** */
+
+type memoryIdempotencyEntry struct {
+    record  *IdempotencyRecord
+    expires time.Time
+}
+
+type memoryIdempotencyExpiry struct {
+    key     string
+    expires time.Time
+}
+
+type memoryIdempotencyStore struct {
+    ttl     time.Duration
+    mutex   sync.Mutex
+    entries map[string]*memoryIdempotencyEntry
+    expiry  []memoryIdempotencyExpiry // in completion order, hence in expiration order as the ttl is fixed
+}
+
+// NewMemoryIdempotencyStore keeps completed records for ttl; a non-positive ttl keeps them forever.
+func NewMemoryIdempotencyStore(ttl time.Duration) IdempotencyStore {
+    return &memoryIdempotencyStore{
+        ttl:     ttl,
+        entries: make(map[string]*memoryIdempotencyEntry),
+    }
+}
+
+func (store *memoryIdempotencyStore) Reserve(key string) (*IdempotencyRecord, error) {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    now := time.Now()
+
+    if entry := store.entries[key]; entry != nil {
+        if entry.record == nil {
+            return nil, rest_error.NewWithReason(http.StatusConflict, ReasonIdempotencyKeyInProgress, "Request with the same idempotency key is in progress")
+        }
+        if entry.expires.IsZero() || now.Before(entry.expires) {
+            return entry.record, nil
+        }
+    }
+
+    store.entries[key] = &memoryIdempotencyEntry{}
+
+    return nil, nil
+}
+
+func (store *memoryIdempotencyStore) Complete(key string, record *IdempotencyRecord) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    entry := &memoryIdempotencyEntry{record: record}
+    if store.ttl > 0 {
+        entry.expires = time.Now().Add(store.ttl)
+    }
+
+    store.entries[key] = entry
+    store.evictExpired()
+
+    if store.ttl > 0 {
+        store.expiry = append(store.expiry, memoryIdempotencyExpiry{key: key, expires: entry.expires})
+    }
+
+    return nil
+}
+
+func (store *memoryIdempotencyStore) Release(key string) error {
+    store.mutex.Lock()
+    defer store.mutex.Unlock()
+
+    if entry := store.entries[key]; entry != nil && entry.record == nil {
+        delete(store.entries, key)
+    }
+
+    return nil
+}
+
+func (store *memoryIdempotencyStore) evictExpired() {
+    now := time.Now()
+    for len(store.expiry) != 0 && !now.Before(store.expiry[0].expires) {
+        expired := store.expiry[0]
+        store.expiry = store.expiry[1:]
+
+        // the key may have been completed again since then
+        if entry := store.entries[expired.key]; entry != nil && entry.record != nil && entry.expires.Equal(expired.expires) {
+            delete(store.entries, expired.key)
+        }
+    }
+}
@@ -0,0 +1,71 @@
+package rest
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+)
+
+type teamPrincipal string
+type userPrincipal string
+
+type accountPrincipal struct {
+    id string
+}
+
+func (principal *accountPrincipal) IdempotencyPrincipal() string {
+    return principal.id
+}
+
+type countingCreates struct {
+    batchDeleteItems
+
+    created int
+}
+
+func (h *countingCreates) Create(*Request, interface{}) (string, error) {
+    h.created++
+    return "1", nil
+}
+
+func TestIdempotencyKeysAreScopedByPrincipal(t *testing.T) {
+    tests := []struct {
+        name       string
+        principals []interface{}
+        created    int
+    }{
+        {name: "same string principal", principals: []interface{}{"alice", "alice"}, created: 1},
+        {name: "types printing the same", principals: []interface{}{teamPrincipal("alice"), userPrincipal("alice")}, created: 2},
+        {name: "string and typed", principals: []interface{}{"alice", userPrincipal("alice")}, created: 2},
+        {name: "same identity", principals: []interface{}{&accountPrincipal{"1"}, &accountPrincipal{"1"}}, created: 1},
+        {name: "different identities", principals: []interface{}{&accountPrincipal{"1"}, &accountPrincipal{"2"}}, created: 2},
+        {name: "anonymous and authenticated", principals: []interface{}{nil, "alice"}, created: 2},
+    }
+
+    for _, test := range tests {
+        handler := &countingCreates{}
+
+        server := NewServer().IdempotencyStore(NewMemoryIdempotencyStore(0))
+        server.Collection("items").Handler(handler)
+
+        for _, principal := range test.principals {
+            server.Authenticator(FixedPrincipal(principal))
+
+            request := httptest.NewRequest("POST", "/items", strings.NewReader(`{}`))
+            request.Header.Set("Content-Type", JsonContentType)
+            request.Header.Set(IdempotencyKeyHeader, "key")
+
+            response := httptest.NewRecorder()
+            server.mux.ServeHTTP(response, request)
+
+            if response.Code != http.StatusCreated {
+                t.Errorf("%s: unexpected status %d", test.name, response.Code)
+            }
+        }
+
+        if handler.created != test.created {
+            t.Errorf("%s: expected %d creates, got %d", test.name, test.created, handler.created)
+        }
+    }
+}
@@ -0,0 +1,251 @@
+package rest
+
+import (
+    "fmt"
+    "github.com/maxmanuylov/go-rest/error"
+    "net/http"
+    "net/url"
+    "reflect"
+    "strconv"
+    "strings"
+)
+
+const (
+    LimitParam  = "limit"
+    OffsetParam = "offset"
+    CursorParam = "cursor"
+    SortParam   = "sort"
+
+    filterParamPrefix = "filter["
+    filterParamSuffix = "]"
+
+    TotalCountHeader = "X-Total-Count"
+
+    ReasonInvalidParameter = "invalid_parameter"
+
+    defaultMaxLimit = 1000
+)
+
+type SortKey struct {
+    Field      string
+    Descending bool
+}
+
+type ListOptions struct {
+    Limit   int
+    Offset  int
+    Cursor  string
+    Sort    []*SortKey
+    Filters map[string][]string
+}
+
+func (options *ListOptions) Filter(field string) string {
+    if values := options.Filters[field]; len(values) != 0 {
+        return values[0]
+    }
+    return ""
+}
+
+type ListPolicy struct {
+    DefaultLimit int
+    MaxLimit     int
+    SortFields   []string
+    FilterFields []string
+}
+
+// Page is a single page of a list; a nil Total means the total count is unknown.
+type Page struct {
+    Items      interface{}
+    Total      *int
+    NextCursor string
+}
+
+type PagedLister interface {
+    ListPage(request *Request, options *ListOptions) (*Page, error)
+}
+
+func (collection *ResourceCollection) ListPolicy(policy *ListPolicy) *ResourceCollection {
+    collection.resourceHandler.listPolicy = policy
+    return collection
+}
+
+// enforcesListOptions tells whether invalid list parameters are rejected; plain handlers that never opted in
+// may interpret the parameters themselves, so for them the parsed options are only a best effort.
+func (resourceHandler *resourceHandlerAdapter) enforcesListOptions() bool {
+    if resourceHandler.listPolicy != nil {
+        return true
+    }
+    switch resourceHandler.extensions.(type) {
+    case PagedLister, StreamingLister:
+        return true
+    default:
+        return false
+    }
+}
+
+func (r *Request) ListOptions() *ListOptions {
+    if r.listOptions == nil {
+        return &ListOptions{}
+    }
+    return r.listOptions
+}
+
+func parseListOptions(query url.Values, policy *ListPolicy) (*ListOptions, error) {
+    if policy == nil {
+        policy = &ListPolicy{}
+    }
+
+    options := &ListOptions{
+        Limit:  policy.DefaultLimit,
+        Cursor: query.Get(CursorParam),
+        Sort:   make([]*SortKey, 0),
+    }
+
+    maxLimit := policy.MaxLimit
+    if maxLimit <= 0 {
+        maxLimit = defaultMaxLimit
+    }
+
+    if limitParam := query.Get(LimitParam); limitParam != "" {
+        limit, err := strconv.Atoi(limitParam)
+        if err != nil || limit <= 0 {
+            return nil, invalidParameter(LimitParam, fmt.Sprintf("Limit must be a positive integer: %s", limitParam))
+        }
+        if limit > maxLimit {
+            return nil, invalidParameter(LimitParam, fmt.Sprintf("Limit must not exceed %d: %s", maxLimit, limitParam))
+        }
+        options.Limit = limit
+    }
+
+    if offsetParam := query.Get(OffsetParam); offsetParam != "" {
+        offset, err := strconv.Atoi(offsetParam)
+        if err != nil || offset < 0 {
+            return nil, invalidParameter(OffsetParam, fmt.Sprintf("Offset must be a non-negative integer: %s", offsetParam))
+        }
+        if options.Cursor != "" {
+            return nil, invalidParameter(OffsetParam, "Offset cannot be combined with cursor")
+        }
+        options.Offset = offset
+    }
+
+    if sortParam := query.Get(SortParam); sortParam != "" {
+        for _, field := range strings.Split(sortParam, ",") {
+            field = strings.TrimSpace(field)
+            key := &SortKey{
+                Field:      strings.TrimPrefix(field, "-"),
+                Descending: strings.HasPrefix(field, "-"),
+            }
+            if key.Field == "" {
+                return nil, invalidParameter(SortParam, fmt.Sprintf("Empty sort key: %s", sortParam))
+            }
+            if policy.SortFields != nil && !contains(policy.SortFields, key.Field) {
+                return nil, invalidParameter(SortParam, fmt.Sprintf("Sorting by field is not supported: %s", key.Field))
+            }
+            options.Sort = append(options.Sort, key)
+        }
+    }
+
+    filters, err := parseFilters(query, policy)
+    if err != nil {
+        return nil, err
+    }
+
+    options.Filters = filters
+
+    return options, nil
+}
+
+func parseFilters(query url.Values, policy *ListPolicy) (map[string][]string, error) {
+    filters := make(map[string][]string)
+
+    for key, values := range query {
+        if !strings.HasPrefix(key, filterParamPrefix) || !strings.HasSuffix(key, filterParamSuffix) {
+            continue
+        }
+        field := key[len(filterParamPrefix):len(key) - len(filterParamSuffix)]
+        if field == "" {
+            return nil, invalidParameter(key, "Empty filter field")
+        }
+        if policy != nil && policy.FilterFields != nil && !contains(policy.FilterFields, field) {
+            return nil, invalidParameter(key, fmt.Sprintf("Filtering by field is not supported: %s", field))
+        }
+        filters[field] = values
+    }
+
+    return filters, nil
+}
+
+func invalidParameter(name, message string) error {
+    return rest_error.NewWithReason(http.StatusBadRequest, ReasonInvalidParameter, message).WithFields(&rest_error.FieldError{
+        Path:    name,
+        Reason:  ReasonInvalidParameter,
+        Message: message,
+    })
+}
+
+func writePageLinks(request *Request, response http.ResponseWriter, options *ListOptions, page *Page) {
+    if page.Total != nil {
+        response.Header().Set(TotalCountHeader, strconv.Itoa(*page.Total))
+    }
+
+    links := make([]string, 0, 2)
+
+    if page.NextCursor != "" {
+        links = append(links, pageLink(request, "next", func(query url.Values) {
+            query.Del(OffsetParam)
+            query.Set(CursorParam, page.NextCursor)
+        }))
+    } else if options.Cursor == "" && options.Limit > 0 {
+        count := itemCount(page.Items)
+        if page.Total != nil && options.Offset + count < *page.Total || page.Total == nil && count == options.Limit {
+            links = append(links, pageLink(request, "next", func(query url.Values) {
+                query.Set(LimitParam, strconv.Itoa(options.Limit))
+                query.Set(OffsetParam, strconv.Itoa(options.Offset + count))
+            }))
+        }
+    }
+
+    if options.Cursor == "" && options.Offset > 0 && options.Limit > 0 {
+        prevOffset := options.Offset - options.Limit
+        if prevOffset < 0 {
+            prevOffset = 0
+        }
+        links = append(links, pageLink(request, "prev", func(query url.Values) {
+            query.Set(LimitParam, strconv.Itoa(options.Limit))
+            query.Set(OffsetParam, strconv.Itoa(prevOffset))
+        }))
+    }
+
+    if len(links) != 0 {
+        response.Header().Set("Link", strings.Join(links, ", "))
+    }
+}
+
+func pageLink(request *Request, rel string, modify func(url.Values)) string {
+    query := request.URL.Query()
+    modify(query)
+
+    link := url.URL{
+        Path:     request.URL.Path,
+        RawQuery: query.Encode(),
+    }
+
+    return fmt.Sprintf("<%s>; rel=\"%s\"", link.String(), rel)
+}
+
+func itemCount(items interface{}) int {
+    value := reflect.ValueOf(items)
+    for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+        if value.IsNil() {
+            return 0
+        }
+        value = value.Elem()
+    }
+
+    switch value.Kind() {
+    case reflect.Array, reflect.Slice, reflect.Map:
+        return value.Len()
+    default:
+        return 0
+    }
+}
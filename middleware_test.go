@@ -0,0 +1,144 @@
+package rest
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "reflect"
+    "testing"
+)
+
+func recordingMiddleware(calls *[]string, name string) Middleware {
+    return func(next Handler) Handler {
+        return HandlerFunc(func(request *Request, response http.ResponseWriter) {
+            *calls = append(*calls, name)
+            next.ServeHTTP(request, response)
+        })
+    }
+}
+
+func serve(server *Server, method, path string) *httptest.ResponseRecorder {
+    response := httptest.NewRecorder()
+    server.mux.ServeHTTP(response, httptest.NewRequest(method, path, nil))
+    return response
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+    calls := make([]string, 0)
+
+    server := NewServer()
+    server.Use(recordingMiddleware(&calls, "server-1"), recordingMiddleware(&calls, "server-2"))
+
+    api := server.WithPrefix("api")
+    api.Use(recordingMiddleware(&calls, "api-1"))
+    api.Use(recordingMiddleware(&calls, "api-2"))
+
+    v1 := api.WithPrefix("v1")
+    v1.Use(recordingMiddleware(&calls, "v1"))
+
+    users := v1.Collection("users")
+    users.Use(recordingMiddleware(&calls, "users-1"), recordingMiddleware(&calls, "users-2"))
+    users.CustomHandlerFunc(func(*Request, http.ResponseWriter) {
+        calls = append(calls, "users-handler")
+    })
+
+    posts := users.SubCollection("posts")
+    posts.Use(recordingMiddleware(&calls, "posts-1"))
+    posts.Use(recordingMiddleware(&calls, "posts-2"))
+    posts.CustomHandlerFunc(func(*Request, http.ResponseWriter) {
+        calls = append(calls, "posts-handler")
+    })
+
+    tests := []struct {
+        path     string
+        expected []string
+    }{
+        {
+            path:     "/api/v1/users",
+            expected: []string{"server-1", "server-2", "api-1", "api-2", "v1", "users-1", "users-2", "users-handler"},
+        },
+        {
+            path:     "/api/v1/users/1",
+            expected: []string{"server-1", "server-2", "api-1", "api-2", "v1", "users-1", "users-2", "users-handler"},
+        },
+        {
+            path:     "/api/v1/users/1/posts",
+            expected: []string{"server-1", "server-2", "api-1", "api-2", "v1", "users-1", "users-2", "posts-1", "posts-2", "posts-handler"},
+        },
+        {
+            path:     "/api/v1/users/1/posts/2",
+            expected: []string{"server-1", "server-2", "api-1", "api-2", "v1", "users-1", "users-2", "posts-1", "posts-2", "posts-handler"},
+        },
+    }
+
+    for _, test := range tests {
+        calls = calls[:0]
+
+        if response := serve(server, "GET", test.path); response.Code != http.StatusOK {
+            t.Errorf("%s: unexpected status %d", test.path, response.Code)
+        }
+
+        if !reflect.DeepEqual(calls, test.expected) {
+            t.Errorf("%s: expected %v, got %v", test.path, test.expected, calls)
+        }
+    }
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+    calls := make([]string, 0)
+
+    server := NewServer()
+    server.Use(recordingMiddleware(&calls, "server"))
+
+    items := server.Collection("items")
+    items.Use(func(Handler) Handler {
+        return HandlerFunc(func(request *Request, response http.ResponseWriter) {
+            calls = append(calls, "deny")
+            response.WriteHeader(http.StatusForbidden)
+        })
+    }, recordingMiddleware(&calls, "after-deny"))
+    items.CustomHandlerFunc(func(*Request, http.ResponseWriter) {
+        calls = append(calls, "handler")
+    })
+
+    if response := serve(server, "GET", "/items"); response.Code != http.StatusForbidden {
+        t.Errorf("unexpected status %d", response.Code)
+    }
+
+    if expected := []string{"server", "deny"}; !reflect.DeepEqual(calls, expected) {
+        t.Errorf("expected %v, got %v", expected, calls)
+    }
+}
+
+func TestMiddlewareSeesAuthFailures(t *testing.T) {
+    statuses := make([]int, 0)
+
+    server := NewServer()
+    server.Authenticator(StaticTokens(map[string]interface{}{"secret": "alice"}))
+    server.Use(func(next Handler) Handler {
+        return HandlerFunc(func(request *Request, response http.ResponseWriter) {
+            recorder := httptest.NewRecorder()
+            next.ServeHTTP(request, recorder)
+            statuses = append(statuses, recorder.Code)
+            response.WriteHeader(recorder.Code)
+        })
+    })
+
+    items := server.Collection("items")
+    items.CustomHandlerFunc(func(request *Request, response http.ResponseWriter) {
+        response.WriteHeader(http.StatusOK)
+    })
+
+    request := httptest.NewRequest("GET", "/items", nil)
+    request.Header.Set("Authorization", "Bearer wrong")
+
+    response := httptest.NewRecorder()
+    server.mux.ServeHTTP(response, request)
+
+    if response.Code != http.StatusUnauthorized {
+        t.Errorf("unexpected status %d", response.Code)
+    }
+
+    if expected := []int{http.StatusUnauthorized}; !reflect.DeepEqual(statuses, expected) {
+        t.Errorf("expected middleware to see %v, got %v", expected, statuses)
+    }
+}
@@ -0,0 +1,568 @@
+package rest
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "reflect"
+    "sort"
+    "strings"
+    "time"
+)
+
+const (
+    OpenAPIVersion = "3.0.3"
+)
+
+type OpenAPIInfo struct {
+    Title       string `json:"title"`
+    Version     string `json:"version"`
+    Description string `json:"description,omitempty"`
+}
+
+type openAPIObject map[string]interface{}
+
+func (server *Server) OpenAPI(pattern string, info OpenAPIInfo) {
+    server.mux.HandleFunc(server.path(pattern), func(response http.ResponseWriter, request *http.Request) {
+        content, err := json.MarshalIndent(server.OpenAPIDocument(info), "", "  ")
+        if err != nil {
+            writeError(response, request, err)
+            return
+        }
+        writeAnswer(response, http.StatusOK, JsonContentType, content)
+    })
+}
+
+func (server *Server) OpenAPIDocument(info OpenAPIInfo) map[string]interface{} {
+    schemas := newSchemaRegistry()
+    paths := openAPIObject{}
+    operationIds := make(map[string]bool)
+
+    schemas.schemas["Error"] = openAPIObject{
+        "type": "object",
+        "properties": openAPIObject{
+            "error": openAPIObject{
+                "type": "object",
+                "properties": openAPIObject{
+                    "code":      openAPIObject{"type": "integer"},
+                    "message":   openAPIObject{"type": "string"},
+                    "reason":    openAPIObject{"type": "string"},
+                    "requestId": openAPIObject{"type": "string"},
+                    "fields": openAPIObject{
+                        "type": "array",
+                        "items": openAPIObject{
+                            "type": "object",
+                            "properties": openAPIObject{
+                                "path":    openAPIObject{"type": "string"},
+                                "reason":  openAPIObject{"type": "string"},
+                                "message": openAPIObject{"type": "string"},
+                            },
+                        },
+                    },
+                },
+            },
+        },
+    }
+
+    server.walkCollections(func(collection *Collection, collectionPath, itemPath string, idParams []*Collection) {
+        adapter, ok := collection.handler.(*resourceHandlerAdapter)
+        if !ok {
+            return
+        }
+
+        target := &openAPITarget{
+            tag:          collection.name,
+            name:         openAPIOperationName(collectionPath),
+            operationIds: operationIds,
+        }
+
+        itemType := reflect.TypeOf(adapter.resourceHandler.EmptyItem())
+        itemSchema := schemas.schemaFor(itemType, readSchema)
+        parentParams := openAPIPathParams(idParams[:len(idParams) - 1])
+        itemParams := openAPIPathParams(idParams)
+
+        listResponse := openAPIJsonResponse("Items", openAPIObject{"type": "array", "items": itemSchema})
+        if _, ok := adapter.extensions.(StreamingLister); ok {
+            listResponse["content"].(openAPIObject)[NdjsonContentType] = openAPIObject{"schema": itemSchema}
+        }
+
+        deleteResponses := openAPIObject{
+            "204": openAPIObject{"description": "Deleted"},
+        }
+        if _, ok := adapter.extensions.(SelectiveBatchDeleter); ok {
+            deleteResponses = openAPIObject{
+                "200": openAPIJsonResponse("Deleted", openAPIObject{
+                    "type":       "object",
+                    "properties": openAPIObject{"deleted": openAPIObject{"type": "integer"}},
+                }),
+            }
+        }
+
+        collectionItem := openAPIObject{
+            "get": openAPIOperation(target, "List", withParams(parentParams, openAPIListParams(adapter.listPolicy)...), nil, openAPIObject{
+                "200": listResponse,
+            }),
+            "post": openAPIOperation(target, "Create", parentParams, schemas.schemaFor(itemType, Create), openAPIObject{
+                "201": openAPIObject{
+                    "description": "Created",
+                    "headers": openAPIObject{
+                        "Location": openAPIObject{"schema": openAPIObject{"type": "string"}},
+                    },
+                },
+            }),
+            "delete": openAPIOperation(target, "BatchDelete", withParams(parentParams, openAPIDeleteParams(adapter.listPolicy)...), nil, deleteResponses),
+        }
+
+        if _, ok := adapter.extensions.(BatchUpdater); ok {
+            collectionItem["patch"] = openAPIOperation(target, "UpdateMany", parentParams, openAPIObject{
+                "type": "array",
+                "items": openAPIObject{
+                    "type": "object",
+                    "properties": openAPIObject{
+                        "id":   openAPIObject{"type": "string"},
+                        "item": schemas.schemaFor(itemType, Update),
+                    },
+                },
+            }, openAPIObject{
+                "207": openAPIObject{"description": "Multi-Status"},
+            })
+        }
+
+        itemItem := openAPIObject{
+            "get": openAPIOperation(target, "Read", itemParams, nil, openAPIObject{
+                "200": openAPIJsonResponse("Item", itemSchema),
+            }),
+            "post": openAPIOperation(target, "Update", itemParams, schemas.schemaFor(itemType, Update), openAPIObject{
+                "200": openAPIObject{"description": "Updated"},
+            }),
+            "put": openAPIOperation(target, "Replace", itemParams, schemas.schemaFor(itemType, Replace), openAPIObject{
+                "200": openAPIObject{"description": "Replaced"},
+            }),
+            "patch": openAPIPatchOperation(target, itemParams, schemas.schemaFor(itemType, patchSchema)),
+            "delete": openAPIOperation(target, "Delete", itemParams, nil, openAPIObject{
+                "200": openAPIObject{"description": "Deleted"},
+            }),
+        }
+
+        for _, method := range adapter.actionMethods() {
+            verb := strings.ToLower(method)
+            if _, taken := itemItem[verb]; taken || !openAPIVerbs[verb] {
+                continue
+            }
+            itemItem[verb] = openAPIOperation(target, capitalize(verb), itemParams, nil, openAPIObject{
+                "200": openAPIObject{"description": "Done"},
+            })
+        }
+
+        paths[collectionPath] = collectionItem
+        paths[itemPath] = itemItem
+    })
+
+    return openAPIObject{
+        "openapi": OpenAPIVersion,
+        "info":    info,
+        "paths":   paths,
+        "components": openAPIObject{
+            "schemas": schemas.schemas,
+        },
+    }
+}
+
+func (server *Server) walkCollections(visit func(collection *Collection, collectionPath, itemPath string, idParams []*Collection)) {
+    var walk func(collection *Collection, collectionPath string, idParams []*Collection)
+
+    walk = func(collection *Collection, collectionPath string, idParams []*Collection) {
+        idParams = append(idParams[:len(idParams):len(idParams)], collection)
+        itemPath := fmt.Sprintf("%s/{%s}", collectionPath, collection.idParamName())
+
+        visit(collection, collectionPath, itemPath, idParams)
+
+        for _, subCollection := range collection.sortedSubCollections() {
+            walk(subCollection, fmt.Sprintf("%s/%s", itemPath, subCollection.name), idParams)
+        }
+    }
+
+    for _, collection := range server.settings.collections {
+        walk(collection, collection.path, nil)
+    }
+}
+
+func openAPIPathParams(idParams []*Collection) []interface{} {
+    params := make([]interface{}, len(idParams))
+    for i, collection := range idParams {
+        params[i] = openAPIObject{
+            "name":     collection.idParamName(),
+            "in":       "path",
+            "required": true,
+            "schema":   idTypeSchema(collection.idType),
+        }
+    }
+    return params
+}
+
+func withParams(params []interface{}, moreParams... interface{}) []interface{} {
+    return append(params[:len(params):len(params)], moreParams...)
+}
+
+func openAPIListParams(policy *ListPolicy) []interface{} {
+    sortDescription := "Comma separated fields, prefixed with - for the descending order"
+    if policy != nil && policy.SortFields != nil {
+        sortDescription = fmt.Sprintf("%s: %s", sortDescription, strings.Join(policy.SortFields, ", "))
+    }
+
+    return []interface{}{
+        openAPIQueryParam(LimitParam, openAPIObject{"type": "integer", "minimum": 0}, ""),
+        openAPIQueryParam(OffsetParam, openAPIObject{"type": "integer", "minimum": 0}, ""),
+        openAPIQueryParam(CursorParam, openAPIObject{"type": "string"}, "Cursor of the next page"),
+        openAPIQueryParam(SortParam, openAPIObject{"type": "string"}, sortDescription),
+        openAPIFilterParam(policy),
+    }
+}
+
+func openAPIDeleteParams(policy *ListPolicy) []interface{} {
+    return []interface{}{
+        openAPIQueryParam(IDsParam, openAPIObject{"type": "string"}, "Comma separated ids of the items to delete"),
+        openAPIQueryParam(IDParam, openAPIObject{"type": "array", "items": openAPIObject{"type": "string"}}, "Id of an item to delete, may be repeated"),
+        openAPIFilterParam(policy),
+        openAPIQueryParam(DeleteAllFlag, openAPIObject{"type": "boolean"}, "Delete all items; required when neither ids nor filters are given"),
+    }
+}
+
+func openAPIQueryParam(name string, schema openAPIObject, description string) openAPIObject {
+    param := openAPIObject{
+        "name":   name,
+        "in":     "query",
+        "schema": schema,
+    }
+    if description != "" {
+        param["description"] = description
+    }
+    return param
+}
+
+// openAPIFilterParam describes the filter[field] parameters as a deepObject
+func openAPIFilterParam(policy *ListPolicy) openAPIObject {
+    schema := openAPIObject{
+        "type":                 "object",
+        "additionalProperties": openAPIObject{"type": "string"},
+    }
+
+    if policy != nil && policy.FilterFields != nil {
+        properties := openAPIObject{}
+        for _, field := range policy.FilterFields {
+            properties[field] = openAPIObject{"type": "string"}
+        }
+        schema = openAPIObject{
+            "type":                 "object",
+            "properties":           properties,
+            "additionalProperties": false,
+        }
+    }
+
+    param := openAPIQueryParam(strings.TrimSuffix(filterParamPrefix, "["), schema, "Field filters, e.g. filter[name]=value")
+    param["style"] = "deepObject"
+    param["explode"] = true
+
+    return param
+}
+
+// openAPITarget names the operations of a single collection; operation ids are derived from the full
+// collection path, so equally named sub-collections under different parents do not clash.
+type openAPITarget struct {
+    tag          string
+    name         string
+    operationIds map[string]bool
+}
+
+// Custom actions are documented as operations only when their method is an OpenAPI path item verb
+// not already taken by a standard item operation; other methods are skipped.
+var openAPIVerbs = map[string]bool{
+    "get": true, "put": true, "post": true, "delete": true, "options": true, "head": true, "patch": true, "trace": true,
+}
+
+func openAPIOperationName(collectionPath string) string {
+    name := ""
+    for _, segment := range strings.Split(collectionPath, "/") {
+        if strings.HasPrefix(segment, "{") {
+            continue
+        }
+        for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+            return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9')
+        }) {
+            name += capitalize(word)
+        }
+    }
+    return name
+}
+
+func (target *openAPITarget) operationId(action string) string {
+    operationId := fmt.Sprintf("%s%s%s", strings.ToLower(action[:1]), action[1:], target.name)
+    for unique, i := operationId, 2; ; i++ {
+        if !target.operationIds[unique] {
+            target.operationIds[unique] = true
+            return unique
+        }
+        unique = fmt.Sprintf("%s%d", operationId, i)
+    }
+}
+
+func openAPIOperation(target *openAPITarget, action string, params []interface{}, requestSchema interface{}, responses openAPIObject) openAPIObject {
+    responses["default"] = openAPIJsonResponse("Error", openAPIObject{"$ref": "#/components/schemas/Error"})
+
+    operation := openAPIObject{
+        "operationId": target.operationId(action),
+        "tags":        []string{target.tag},
+        "responses":   responses,
+    }
+
+    if len(params) != 0 {
+        operation["parameters"] = params
+    }
+
+    if requestSchema != nil {
+        operation["requestBody"] = openAPIObject{
+            "required": true,
+            "content": openAPIObject{
+                JsonContentType: openAPIObject{"schema": requestSchema},
+                YamlContentType: openAPIObject{"schema": requestSchema},
+            },
+        }
+    }
+
+    return operation
+}
+
+func openAPIPatchOperation(target *openAPITarget, params []interface{}, mergePatchSchema interface{}) openAPIObject {
+    operation := openAPIOperation(target, "Patch", params, nil, openAPIObject{
+        "200": openAPIObject{"description": "Patched"},
+    })
+    operation["requestBody"] = openAPIObject{
+        "required": true,
+        "content": openAPIObject{
+            MergePatchContentType: openAPIObject{"schema": mergePatchSchema},
+            JsonPatchContentType: openAPIObject{"schema": openAPIObject{
+                "type": "array",
+                "items": openAPIObject{
+                    "type":     "object",
+                    "required": []string{"op", "path"},
+                    "properties": openAPIObject{
+                        "op":    openAPIObject{"type": "string", "enum": []string{"add", "remove", "replace", "move", "copy", "test"}},
+                        "path":  openAPIObject{"type": "string"},
+                        "from":  openAPIObject{"type": "string"},
+                        "value": openAPIObject{},
+                    },
+                },
+            }},
+        },
+    }
+    return operation
+}
+
+func capitalize(str string) string {
+    if str == "" {
+        return str
+    }
+    return fmt.Sprintf("%s%s", strings.ToUpper(str[:1]), str[1:])
+}
+
+func openAPIJsonResponse(description string, schema interface{}) openAPIObject {
+    return openAPIObject{
+        "description": description,
+        "content": openAPIObject{
+            JsonContentType: openAPIObject{"schema": schema},
+        },
+    }
+}
+
+/* *** */
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Component schemas are emitted per action, as required fields differ between them: the plain type name
+// describes responses, suffixed names (UserCreate, UserPatch...) describe request bodies.
+const (
+    readSchema  ItemAction = ""
+    patchSchema ItemAction = "patch"
+)
+
+type schemaKey struct {
+    t      reflect.Type
+    action ItemAction
+}
+
+type schemaRegistry struct {
+    schemas openAPIObject
+    names   map[schemaKey]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+    return &schemaRegistry{
+        schemas: openAPIObject{},
+        names:   make(map[schemaKey]string),
+    }
+}
+
+func (registry *schemaRegistry) schemaFor(t reflect.Type, action ItemAction) openAPIObject {
+    if t == nil {
+        return openAPIObject{}
+    }
+
+    for t.Kind() == reflect.Ptr {
+        t = t.Elem()
+    }
+
+    if t == timeType {
+        return openAPIObject{"type": "string", "format": "date-time"}
+    }
+
+    switch t.Kind() {
+    case reflect.Bool:
+        return openAPIObject{"type": "boolean"}
+    case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+        return openAPIObject{"type": "integer", "format": "int32"}
+    case reflect.Int64, reflect.Uint64:
+        return openAPIObject{"type": "integer", "format": "int64"}
+    case reflect.Float32:
+        return openAPIObject{"type": "number", "format": "float"}
+    case reflect.Float64:
+        return openAPIObject{"type": "number", "format": "double"}
+    case reflect.String:
+        return openAPIObject{"type": "string"}
+    case reflect.Slice, reflect.Array:
+        if t.Elem().Kind() == reflect.Uint8 {
+            return openAPIObject{"type": "string", "format": "byte"}
+        }
+        return openAPIObject{"type": "array", "items": registry.schemaFor(t.Elem(), action)}
+    case reflect.Map:
+        return openAPIObject{"type": "object", "additionalProperties": registry.schemaFor(t.Elem(), action)}
+    case reflect.Struct:
+        if t.Name() == "" {
+            return registry.structSchema(t, action)
+        }
+        return openAPIObject{"$ref": fmt.Sprintf("#/components/schemas/%s", registry.register(t, action))}
+    default:
+        return openAPIObject{}
+    }
+}
+
+func (registry *schemaRegistry) register(t reflect.Type, action ItemAction) string {
+    key := schemaKey{t: t, action: action}
+    if name, ok := registry.names[key]; ok {
+        return name
+    }
+
+    name := fmt.Sprintf("%s%s", t.Name(), capitalize(string(action)))
+    if _, taken := registry.schemas[name]; taken {
+        name = fmt.Sprintf("%s%s", capitalize(strings.Replace(t.PkgPath(), "/", "_", -1)), name)
+    }
+
+    registry.names[key] = name
+    registry.schemas[name] = openAPIObject{}
+    registry.schemas[name] = registry.structSchema(t, action)
+
+    return name
+}
+
+// structSchema marks required fields and one-of groups for the create, update and replace actions only:
+// responses promise nothing beyond the properties and merge patches may omit any field.
+func (registry *schemaRegistry) structSchema(t reflect.Type, action ItemAction) openAPIObject {
+    checked := action != readSchema && action != patchSchema
+
+    properties := openAPIObject{}
+    required := make([]string, 0)
+    oneOfGroups := make(map[string]*oneOfData)
+    oneOfKeys := make([]string, 0)
+
+    var collect func(t reflect.Type)
+    collect = func(t reflect.Type) {
+        for i := 0; i < t.NumField(); i++ {
+            fieldType := t.Field(i)
+            if fieldType.PkgPath != "" && !fieldType.Anonymous || fieldType.Tag.Get("json") == "-" {
+                continue
+            }
+
+            if fieldType.Anonymous && fieldType.Tag.Get("json") == "" {
+                embedded := fieldType.Type
+                for embedded.Kind() == reflect.Ptr {
+                    embedded = embedded.Elem()
+                }
+                if embedded.Kind() == reflect.Struct {
+                    collect(embedded)
+                    continue
+                }
+            }
+
+            name := getFieldName(fieldType)
+            r := getRestrictions(fieldType, action)
+            schema := registry.schemaFor(fieldType.Type, action)
+
+            if r.readOnly {
+                schema = withSchemaFlag(schema, "readOnly", true)
+            }
+            if r.nonEmptyArray {
+                schema = withSchemaFlag(schema, "minItems", 1)
+            }
+
+            properties[name] = schema
+
+            if !checked {
+                continue
+            }
+
+            if len(r.oneOfKeys) != 0 {
+                for _, oneOfKey := range r.oneOfKeys {
+                    if _, ok := oneOfGroups[oneOfKey]; !ok {
+                        oneOfKeys = append(oneOfKeys, oneOfKey)
+                    }
+                    getOrCreateOneOf(oneOfGroups, oneOfKey).addZeroField(name, r.required)
+                }
+            } else if r.required {
+                required = append(required, name)
+            }
+        }
+    }
+
+    collect(t)
+
+    schema := openAPIObject{
+        "type":       "object",
+        "properties": properties,
+    }
+
+    if len(required) != 0 {
+        sort.Strings(required)
+        schema["required"] = required
+    }
+
+    groups := make([]interface{}, 0, len(oneOfKeys))
+    for _, oneOfKey := range oneOfKeys {
+        data := oneOfGroups[oneOfKey]
+        alternatives := make([]interface{}, 0, len(data.fieldPaths) + 1)
+        for _, name := range data.fieldPaths {
+            alternatives = append(alternatives, openAPIObject{"required": []string{name}})
+        }
+        if !data.required {
+            alternatives = append(alternatives, openAPIObject{"not": openAPIObject{"anyOf": alternatives[:len(alternatives):len(alternatives)]}})
+        }
+        groups = append(groups, openAPIObject{"oneOf": alternatives})
+    }
+
+    if len(groups) == 1 {
+        schema["oneOf"] = groups[0].(openAPIObject)["oneOf"]
+    } else if len(groups) > 1 {
+        schema["allOf"] = groups
+    }
+
+    return schema
+}
+
+func withSchemaFlag(schema openAPIObject, key string, value interface{}) openAPIObject {
+    if _, isRef := schema["$ref"]; isRef {
+        return openAPIObject{
+            "allOf": []interface{}{schema},
+            key:     value,
+        }
+    }
+    schema[key] = value
+    return schema
+}
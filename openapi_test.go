@@ -0,0 +1,46 @@
+package rest
+
+import (
+    "reflect"
+    "testing"
+)
+
+type openAPIUser struct {
+    ID    string `json:"id" rest:"readonly"`
+    Name  string `json:"name" rest:"required@create:replace"`
+    Email string `json:"email" rest:"required@*"`
+}
+
+type openAPIUsers struct {
+    batchDeleteItems
+}
+
+func (h *openAPIUsers) EmptyItem() interface{} {
+    return &openAPIUser{}
+}
+
+func TestOpenAPISchemasPerAction(t *testing.T) {
+    server := NewServer()
+    server.Collection("users").Handler(&openAPIUsers{})
+
+    schemas := server.OpenAPIDocument(OpenAPIInfo{Title: "Test", Version: "1"})["components"].(openAPIObject)["schemas"].(openAPIObject)
+
+    expected := map[string][]string{
+        "openAPIUser":        nil,
+        "openAPIUserCreate":  {"email", "name"},
+        "openAPIUserUpdate":  {"email"},
+        "openAPIUserReplace": {"email", "name"},
+        "openAPIUserPatch":   nil,
+    }
+
+    for name, required := range expected {
+        schema, ok := schemas[name].(openAPIObject)
+        if !ok {
+            t.Errorf("%s: schema is missing", name)
+            continue
+        }
+        if actual, _ := schema["required"].([]string); !reflect.DeepEqual(actual, required) {
+            t.Errorf("%s: expected required %v, got %v", name, required, actual)
+        }
+    }
+}
@@ -0,0 +1,495 @@
+package rest
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "github.com/maxmanuylov/go-rest/error"
+    "math/big"
+    "mime"
+    "net/http"
+    "strconv"
+    "strings"
+)
+
+const (
+    MergePatchContentType = "application/merge-patch+json"
+    JsonPatchContentType  = "application/json-patch+json"
+
+    ReasonInvalidPatch = "invalid_patch"
+    ReasonPatchFailed  = "patch_failed"
+)
+
+type PatchOperation struct {
+    Op    string          `json:"op"`
+    Path  string          `json:"path"`
+    From  string          `json:"from,omitempty"`
+    Value json.RawMessage `json:"value,omitempty"`
+}
+
+func (resourceHandler *resourceHandlerAdapter) handlePatch(request *Request, response http.ResponseWriter) {
+    expectedVersion, err := resourceHandler.checkIfMatch(request)
+    if err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
+    item, err := resourceHandler.readPatchedItem(request)
+    if err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
+    if err := resourceHandler.update(request, response, item, Update, expectedVersion); err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
+    writeAnswer(response, http.StatusOK, "", nil)
+}
+
+func (resourceHandler *resourceHandlerAdapter) readPatchedItem(request *Request) (interface{}, error) {
+    mediaType, _, err := mime.ParseMediaType(request.Header.Get("Content-Type"))
+    if err != nil || mediaType != MergePatchContentType && mediaType != JsonPatchContentType {
+        return nil, rest_error.New(http.StatusUnsupportedMediaType, fmt.Sprintf("PATCH requires %s or %s", MergePatchContentType, JsonPatchContentType))
+    }
+
+    patchContent, err := request.ReadBody()
+    if err != nil {
+        return nil, err
+    }
+
+    current, err := resourceHandler.resourceHandler.Read(request)
+    if err != nil {
+        return nil, err
+    }
+
+    if isNil(current) {
+        return nil, rest_error.NewByCode(http.StatusNotFound)
+    }
+
+    original, err := toJsonValue(current)
+    if err != nil {
+        return nil, err
+    }
+
+    var patched interface{}
+
+    if mediaType == MergePatchContentType {
+        patch, err := decodeJsonValue(patchContent)
+        if err != nil {
+            return nil, rest_error.NewWithReason(http.StatusBadRequest, ReasonInvalidPatch, err.Error())
+        }
+        patched = applyMergePatch(deepCopy(original), patch)
+    } else {
+        operations := make([]*PatchOperation, 0)
+        if err := json.Unmarshal(patchContent, &operations); err != nil {
+            return nil, rest_error.NewWithReason(http.StatusBadRequest, ReasonInvalidPatch, err.Error())
+        }
+        if patched, err = applyJsonPatch(deepCopy(original), operations); err != nil {
+            return nil, err
+        }
+    }
+
+    patchedJson, err := json.Marshal(patched)
+    if err != nil {
+        return nil, err
+    }
+
+    item := resourceHandler.resourceHandler.EmptyItem()
+
+    if err := decodeJson(request, patchedJson, item); err != nil {
+        return nil, err
+    }
+
+    if err := CheckRestrictions(item, Update); err != nil {
+        if remaining := changedViolations(err.(*ValidationError), original, patched); len(remaining) != 0 {
+            return nil, &ValidationError{Violations: remaining}
+        }
+    }
+
+    return item, nil
+}
+
+func changedViolations(err *ValidationError, original, patched interface{}) []*Violation {
+    violations := make([]*Violation, 0, len(err.Violations))
+    for _, violation := range err.Violations {
+        if violation.Problem == ProblemReadOnly {
+            originalValue, _ := valueAtFieldPath(original, violation.Path)
+            patchedValue, _ := valueAtFieldPath(patched, violation.Path)
+            if jsonEqual(originalValue, patchedValue) {
+                continue
+            }
+        }
+        violations = append(violations, violation)
+    }
+    return violations
+}
+
+func valueAtFieldPath(value interface{}, path string) (interface{}, bool) {
+    for path != "" {
+        var key string
+        if strings.HasPrefix(path, ".") {
+            end := strings.IndexAny(path[1:], ".[")
+            if end == -1 {
+                key, path = path[1:], ""
+            } else {
+                key, path = path[1:end + 1], path[end + 1:]
+            }
+        } else if strings.HasPrefix(path, "[") {
+            end := strings.Index(path, "]")
+            if end == -1 {
+                return nil, false
+            }
+            key, path = path[1:end], path[end + 1:]
+        } else {
+            return nil, false
+        }
+
+        switch container := value.(type) {
+        case map[string]interface{}:
+            child, ok := container[key]
+            if !ok {
+                return nil, false
+            }
+            value = child
+        case []interface{}:
+            index, err := strconv.Atoi(key)
+            if err != nil || index < 0 || index >= len(container) {
+                return nil, false
+            }
+            value = container[index]
+        default:
+            return nil, false
+        }
+    }
+    return value, true
+}
+
+func toJsonValue(v interface{}) (interface{}, error) {
+    content, err := json.Marshal(v)
+    if err != nil {
+        return nil, err
+    }
+    return decodeJsonValue(content)
+}
+
+func decodeJsonValue(content []byte) (interface{}, error) {
+    decoder := json.NewDecoder(bytes.NewReader(content))
+    decoder.UseNumber()
+
+    var value interface{}
+    if err := decoder.Decode(&value); err != nil {
+        return nil, err
+    }
+    return value, nil
+}
+
+func deepCopy(value interface{}) interface{} {
+    switch container := value.(type) {
+    case map[string]interface{}:
+        newContainer := make(map[string]interface{}, len(container))
+        for key, child := range container {
+            newContainer[key] = deepCopy(child)
+        }
+        return newContainer
+    case []interface{}:
+        newContainer := make([]interface{}, len(container))
+        for i, child := range container {
+            newContainer[i] = deepCopy(child)
+        }
+        return newContainer
+    default:
+        return value
+    }
+}
+
+// jsonEqual compares decoded JSON values, numbers by their numeric value as RFC 6902 requires
+func jsonEqual(a, b interface{}) bool {
+    switch a := a.(type) {
+    case map[string]interface{}:
+        b, ok := b.(map[string]interface{})
+        if !ok || len(a) != len(b) {
+            return false
+        }
+        for key, child := range a {
+            if other, exists := b[key]; !exists || !jsonEqual(child, other) {
+                return false
+            }
+        }
+        return true
+    case []interface{}:
+        b, ok := b.([]interface{})
+        if !ok || len(a) != len(b) {
+            return false
+        }
+        for i := range a {
+            if !jsonEqual(a[i], b[i]) {
+                return false
+            }
+        }
+        return true
+    case json.Number:
+        b, ok := b.(json.Number)
+        if !ok {
+            return false
+        }
+        x, xOk := new(big.Rat).SetString(string(a))
+        y, yOk := new(big.Rat).SetString(string(b))
+        if !xOk || !yOk {
+            return a == b
+        }
+        return x.Cmp(y) == 0
+    default:
+        return a == b
+    }
+}
+
+/* RFC 7396 */
+
+func applyMergePatch(target, patch interface{}) interface{} {
+    patchObject, ok := patch.(map[string]interface{})
+    if !ok {
+        return patch
+    }
+
+    targetObject, ok := target.(map[string]interface{})
+    if !ok {
+        targetObject = make(map[string]interface{})
+    }
+
+    for key, value := range patchObject {
+        if value == nil {
+            delete(targetObject, key)
+        } else {
+            targetObject[key] = applyMergePatch(targetObject[key], value)
+        }
+    }
+
+    return targetObject
+}
+
+/* RFC 6902 */
+
+func applyJsonPatch(doc interface{}, operations []*PatchOperation) (interface{}, error) {
+    for i, operation := range operations {
+        var err error
+        if doc, err = applyPatchOperation(doc, operation); err != nil {
+            return nil, rest_error.NewWithReason(http.StatusConflict, ReasonPatchFailed, err.Error()).WithFields(&rest_error.FieldError{
+                Path:    fmt.Sprintf("[%d]", i),
+                Reason:  ReasonPatchFailed,
+                Message: err.Error(),
+            })
+        }
+    }
+    return doc, nil
+}
+
+func applyPatchOperation(doc interface{}, operation *PatchOperation) (interface{}, error) {
+    path, err := parsePointer(operation.Path)
+    if err != nil {
+        return nil, err
+    }
+
+    switch operation.Op {
+    case "add", "replace", "test":
+        if operation.Value == nil {
+            return nil, fmt.Errorf("Missing value for %s operation: %s", operation.Op, operation.Path)
+        }
+        value, err := decodeJsonValue(operation.Value)
+        if err != nil {
+            return nil, err
+        }
+        if operation.Op == "test" {
+            actual, err := getAtPointer(doc, path)
+            if err != nil {
+                return nil, err
+            }
+            if !jsonEqual(actual, value) {
+                return nil, fmt.Errorf("Test failed: %s", operation.Path)
+            }
+            return doc, nil
+        }
+        return setAtPointer(doc, path, value, operation.Op == "add")
+
+    case "remove":
+        newDoc, _, err := removeAtPointer(doc, path)
+        return newDoc, err
+
+    case "move", "copy":
+        from, err := parsePointer(operation.From)
+        if err != nil {
+            return nil, err
+        }
+        var value interface{}
+        if operation.Op == "move" {
+            if strings.HasPrefix(operation.Path + "/", operation.From + "/") && operation.Path != operation.From {
+                return nil, fmt.Errorf("Cannot move a value into itself: %s", operation.From)
+            }
+            if doc, value, err = removeAtPointer(doc, from); err != nil {
+                return nil, err
+            }
+        } else {
+            if value, err = getAtPointer(doc, from); err != nil {
+                return nil, err
+            }
+            value = deepCopy(value)
+        }
+        return setAtPointer(doc, path, value, true)
+
+    default:
+        return nil, fmt.Errorf("Unknown operation: %s", operation.Op)
+    }
+}
+
+func parsePointer(pointer string) ([]string, error) {
+    if pointer == "" {
+        return []string{}, nil
+    }
+    if !strings.HasPrefix(pointer, "/") {
+        return nil, fmt.Errorf("Invalid JSON pointer: %s", pointer)
+    }
+
+    tokens := strings.Split(pointer[1:], "/")
+    for i, token := range tokens {
+        tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+    }
+    return tokens, nil
+}
+
+func arrayIndex(token string, length int, allowEnd bool) (int, error) {
+    if allowEnd && token == "-" {
+        return length, nil
+    }
+
+    index, err := strconv.Atoi(token)
+    if err != nil || index < 0 || index > length || index == length && !allowEnd || token != strconv.Itoa(index) {
+        return 0, fmt.Errorf("Invalid array index: %s", token)
+    }
+    return index, nil
+}
+
+func getAtPointer(doc interface{}, path []string) (interface{}, error) {
+    for _, token := range path {
+        switch container := doc.(type) {
+        case map[string]interface{}:
+            child, ok := container[token]
+            if !ok {
+                return nil, fmt.Errorf("Path is not found: %s", token)
+            }
+            doc = child
+        case []interface{}:
+            index, err := arrayIndex(token, len(container), false)
+            if err != nil {
+                return nil, err
+            }
+            doc = container[index]
+        default:
+            return nil, fmt.Errorf("Path is not found: %s", token)
+        }
+    }
+    return doc, nil
+}
+
+func setAtPointer(doc interface{}, path []string, value interface{}, insert bool) (interface{}, error) {
+    if len(path) == 0 {
+        return value, nil
+    }
+
+    token := path[0]
+
+    switch container := doc.(type) {
+    case map[string]interface{}:
+        child, exists := container[token]
+        if len(path) == 1 {
+            if !insert && !exists {
+                return nil, fmt.Errorf("Path is not found: %s", token)
+            }
+            container[token] = value
+            return container, nil
+        }
+        if !exists {
+            return nil, fmt.Errorf("Path is not found: %s", token)
+        }
+        newChild, err := setAtPointer(child, path[1:], value, insert)
+        if err != nil {
+            return nil, err
+        }
+        container[token] = newChild
+        return container, nil
+
+    case []interface{}:
+        if len(path) == 1 && insert {
+            index, err := arrayIndex(token, len(container), true)
+            if err != nil {
+                return nil, err
+            }
+            container = append(container, nil)
+            copy(container[index + 1:], container[index:])
+            container[index] = value
+            return container, nil
+        }
+        index, err := arrayIndex(token, len(container), false)
+        if err != nil {
+            return nil, err
+        }
+        if len(path) == 1 {
+            container[index] = value
+            return container, nil
+        }
+        newChild, err := setAtPointer(container[index], path[1:], value, insert)
+        if err != nil {
+            return nil, err
+        }
+        container[index] = newChild
+        return container, nil
+
+    default:
+        return nil, fmt.Errorf("Path is not found: %s", token)
+    }
+}
+
+func removeAtPointer(doc interface{}, path []string) (interface{}, interface{}, error) {
+    if len(path) == 0 {
+        return nil, nil, fmt.Errorf("Cannot remove the whole document")
+    }
+
+    token := path[0]
+
+    switch container := doc.(type) {
+    case map[string]interface{}:
+        child, exists := container[token]
+        if !exists {
+            return nil, nil, fmt.Errorf("Path is not found: %s", token)
+        }
+        if len(path) == 1 {
+            delete(container, token)
+            return container, child, nil
+        }
+        newChild, removed, err := removeAtPointer(child, path[1:])
+        if err != nil {
+            return nil, nil, err
+        }
+        container[token] = newChild
+        return container, removed, nil
+
+    case []interface{}:
+        index, err := arrayIndex(token, len(container), false)
+        if err != nil {
+            return nil, nil, err
+        }
+        if len(path) == 1 {
+            removed := container[index]
+            return append(container[:index], container[index + 1:]...), removed, nil
+        }
+        newChild, removed, err := removeAtPointer(container[index], path[1:])
+        if err != nil {
+            return nil, nil, err
+        }
+        container[index] = newChild
+        return container, removed, nil
+
+    default:
+        return nil, nil, fmt.Errorf("Path is not found: %s", token)
+    }
+}
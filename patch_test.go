@@ -0,0 +1,38 @@
+package rest
+
+import (
+    "encoding/json"
+    "testing"
+)
+
+func TestJsonPatchTestComparesNumbersByValue(t *testing.T) {
+    doc, err := decodeJsonValue([]byte(`{"count":5,"price":1.50,"tags":[1,{"n":2e0}]}`))
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    tests := []struct {
+        operation string
+        passes    bool
+    }{
+        {operation: `{"op":"test","path":"/count","value":5.0}`, passes: true},
+        {operation: `{"op":"test","path":"/count","value":5}`, passes: true},
+        {operation: `{"op":"test","path":"/count","value":50e-1}`, passes: true},
+        {operation: `{"op":"test","path":"/price","value":1.5}`, passes: true},
+        {operation: `{"op":"test","path":"/tags","value":[1.0,{"n":2}]}`, passes: true},
+        {operation: `{"op":"test","path":"/count","value":5.000001}`, passes: false},
+        {operation: `{"op":"test","path":"/count","value":"5"}`, passes: false},
+        {operation: `{"op":"test","path":"/tags","value":[1,{"n":2,"m":3}]}`, passes: false},
+    }
+
+    for _, test := range tests {
+        operation := &PatchOperation{}
+        if err := json.Unmarshal([]byte(test.operation), operation); err != nil {
+            t.Fatal(err)
+        }
+
+        if _, err := applyPatchOperation(doc, operation); (err == nil) != test.passes {
+            t.Errorf("%s: expected passing %v, got %v", test.operation, test.passes, err)
+        }
+    }
+}
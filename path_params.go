@@ -0,0 +1,128 @@
+package rest
+
+import (
+    "fmt"
+    "regexp"
+    "strconv"
+)
+
+type IDType struct {
+    schemaType string
+    format     string
+    pattern    string
+    validate   func(id string) bool
+}
+
+var (
+    AnyID = &IDType{
+        schemaType: "string",
+        validate:   func(string) bool { return true },
+    }
+
+    IntID = &IDType{
+        schemaType: "integer",
+        format:     "int64",
+        validate: func(id string) bool {
+            _, err := strconv.ParseInt(id, 10, 64)
+            return err == nil
+        },
+    }
+
+    UUID = withFormat(PatternID("[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}"), "uuid")
+)
+
+func PatternID(pattern string) *IDType {
+    anchored := fmt.Sprintf("^(?:%s)$", pattern)
+    re := regexp.MustCompile(anchored)
+    return &IDType{
+        schemaType: "string",
+        pattern:    anchored,
+        validate:   re.MatchString,
+    }
+}
+
+func withFormat(idType *IDType, format string) *IDType {
+    idType.format = format
+    return idType
+}
+
+func (collection *Collection) ID(name string, idType *IDType) *Collection {
+    if name == "" {
+        panic("Empty id parameter name")
+    }
+
+    collection.checkIDParamName(name)
+
+    if idType == nil {
+        idType = AnyID
+    }
+
+    collection.idName = name
+    collection.idType = idType
+
+    return collection
+}
+
+// checkIDParamName panics if the name is already used by an ancestor or a descendant of the collection.
+func (collection *Collection) checkIDParamName(name string) {
+    for ancestor := collection.parent; ancestor != nil; ancestor = ancestor.parent {
+        if ancestor.idParamName() == name {
+            panic(fmt.Sprintf("Duplicate id parameter name: %s", name))
+        }
+    }
+
+    var checkDescendants func(parent *Collection)
+    checkDescendants = func(parent *Collection) {
+        for _, descendant := range parent.subCollections {
+            if descendant.idParamName() == name {
+                panic(fmt.Sprintf("Duplicate id parameter name: %s", name))
+            }
+            checkDescendants(descendant)
+        }
+    }
+
+    checkDescendants(collection)
+}
+
+func (collection *Collection) idParamName() string {
+    if collection.idName != "" {
+        return collection.idName
+    }
+    return fmt.Sprintf("%sId", collection.name)
+}
+
+func (collection *Collection) checkID(id string) error {
+    if collection.idType == nil || collection.idType.validate(id) {
+        return nil
+    }
+    return invalidParameter(collection.idParamName(), fmt.Sprintf("Invalid %s: %s", collection.idParamName(), id))
+}
+
+func (r *Request) Param(name string) string {
+    return r.params[name]
+}
+
+func (r *Request) Params() map[string]string {
+    params := make(map[string]string, len(r.params))
+    for name, value := range r.params {
+        params[name] = value
+    }
+    return params
+}
+
+func idTypeSchema(idType *IDType) map[string]interface{} {
+    if idType == nil {
+        idType = AnyID
+    }
+
+    schema := map[string]interface{}{
+        "type": idType.schemaType,
+    }
+    if idType.format != "" {
+        schema["format"] = idType.format
+    }
+    if idType.pattern != "" && idType.format == "" {
+        schema["pattern"] = idType.pattern
+    }
+    return schema
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+    "bufio"
+    "github.com/maxmanuylov/go-rest/error"
+    "log"
+    "net"
+    "net/http"
+    "runtime/debug"
+)
+
+const (
+    ReasonPanic = "internal_panic"
+)
+
+type PanicHandler func(request *Request, value interface{}, stack []byte)
+
+func (server *Server) SetLogger(logger *log.Logger) *Server {
+    server.settings.logger = logger
+    return server
+}
+
+func (server *Server) OnPanic(handler PanicHandler) *Server {
+    server.settings.panicHandlers = append(server.settings.panicHandlers, handler)
+    return server
+}
+
+func (server *Server) logf(format string, args... interface{}) {
+    if server.settings.logger != nil {
+        server.settings.logger.Printf(format, args...)
+    } else {
+        log.Printf(format, args...)
+    }
+}
+
+func (r *Request) logf(format string, args... interface{}) {
+    if r.server == nil {
+        log.Printf(format, args...)
+    } else {
+        r.server.logf(format, args...)
+    }
+}
+
+func (server *Server) recoverPanic(request *Request, response *trackingResponseWriter) {
+    value := recover()
+    if value == nil {
+        return
+    }
+
+    if value == http.ErrAbortHandler {
+        panic(value)
+    }
+
+    stack := debug.Stack()
+
+    server.logf("rest: panic serving %s %s: %v\n%s", request.Method, request.URL.Path, value, stack)
+
+    for _, handler := range server.settings.panicHandlers {
+        handler(request, value, stack)
+    }
+
+    if !response.wroteHeader {
+        writeError(response, request.Request, rest_error.NewWithReason(http.StatusInternalServerError, ReasonPanic, "Internal server error"))
+    }
+}
+
+/* *** */
+
+type trackingResponseWriter struct {
+    http.ResponseWriter
+
+    wroteHeader bool
+}
+
+func (response *trackingResponseWriter) WriteHeader(status int) {
+    if status >= 200 {
+        response.wroteHeader = true
+    }
+    response.ResponseWriter.WriteHeader(status)
+}
+
+func (response *trackingResponseWriter) Write(content []byte) (int, error) {
+    response.wroteHeader = true
+    return response.ResponseWriter.Write(content)
+}
+
+func (response *trackingResponseWriter) Flush() {
+    if flusher, ok := response.ResponseWriter.(http.Flusher); ok {
+        response.wroteHeader = true
+        flusher.Flush()
+    }
+}
+
+func (response *trackingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+    if hijacker, ok := response.ResponseWriter.(http.Hijacker); ok {
+        response.wroteHeader = true
+        return hijacker.Hijack()
+    }
+    return nil, nil, http.ErrNotSupported
+}
+
+func (response *trackingResponseWriter) Unwrap() http.ResponseWriter {
+    return response.ResponseWriter
+}
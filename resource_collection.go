@@ -4,7 +4,7 @@ import (
     "encoding/json"
     "fmt"
     "github.com/maxmanuylov/go-rest/error"
-    "io/ioutil"
+    "math"
     "net/http"
     "path"
     "reflect"
@@ -37,7 +37,10 @@ type ActionHandler interface {
 
 type resourceHandlerAdapter struct {
     resourceHandler ResourceHandler
+    extensions      interface{}
     customActions   map[string]ActionHandler
+    listPolicy      *ListPolicy
+    authorizers     map[Operation]Authorizer
 }
 
 type ResourceCollection struct {
@@ -49,7 +52,9 @@ type ResourceCollection struct {
 func (collection *Collection) Handler(handler ResourceHandler) *ResourceCollection {
     resourceHandler := &resourceHandlerAdapter{
         resourceHandler: handler,
+        extensions:      handler,
         customActions:   make(map[string]ActionHandler),
+        authorizers:     make(map[Operation]Authorizer),
     }
 
     return &ResourceCollection{
@@ -85,7 +90,7 @@ func (resourceHandler *resourceHandlerAdapter) ServeHTTP(request *Request, respo
     method := strings.ToUpper(request.Method)
 
     switch method {
-    case "GET":
+    case "GET", "HEAD":
         if collectionRequest {
             resourceHandler.handleList(request, response)
             return
@@ -109,6 +114,15 @@ func (resourceHandler *resourceHandlerAdapter) ServeHTTP(request *Request, respo
             return
         }
 
+    case "PATCH":
+        if !collectionRequest {
+            resourceHandler.handlePatch(request, response)
+            return
+        } else if batchUpdater, ok := resourceHandler.extensions.(BatchUpdater); ok {
+            resourceHandler.handleBatchUpdate(request, response, batchUpdater)
+            return
+        }
+
     case "DELETE":
         if collectionRequest {
             resourceHandler.handleBatchDelete(request, response)
@@ -118,6 +132,11 @@ func (resourceHandler *resourceHandlerAdapter) ServeHTTP(request *Request, respo
             return
         }
 
+    case "OPTIONS":
+        response.Header().Set("Allow", strings.Join(resourceHandler.allowedMethods(collectionRequest), ", "))
+        writeAnswer(response, http.StatusNoContent, "", nil)
+        return
+
     default:
         if !collectionRequest {
             if handler := resourceHandler.customActions[method]; handler != nil {
@@ -127,121 +146,236 @@ func (resourceHandler *resourceHandlerAdapter) ServeHTTP(request *Request, respo
         }
     }
 
-    writeError(response, ErrMethodNotAllowed)
+    response.Header().Set("Allow", strings.Join(resourceHandler.allowedMethods(collectionRequest), ", "))
+    writeError(response, request.Request, ErrMethodNotAllowed)
 }
 
 func (resourceHandler *resourceHandlerAdapter) handleList(request *Request, response http.ResponseWriter) {
-    items, err := resourceHandler.resourceHandler.List(request)
+    enforced := resourceHandler.enforcesListOptions()
+
+    policy := resourceHandler.listPolicy
+    if !enforced {
+        policy = &ListPolicy{MaxLimit: math.MaxInt32}
+    }
+
+    options, err := parseListOptions(request.URL.Query(), policy)
     if err != nil {
-        writeError(response, err)
+        if enforced {
+            writeError(response, request.Request, err)
+            return
+        }
+        options = nil
+    }
+
+    request.listOptions = options
+
+    if streamingLister, ok := resourceHandler.streamingLister(request); ok {
+        resourceHandler.handleStreamingList(request, response, streamingLister, options)
         return
     }
 
-    itemsJson, err := request.Marshal(items)
-    if err != nil {
-        writeError(response, err)
+    var items interface{}
+
+    if pagedLister, ok := resourceHandler.extensions.(PagedLister); ok {
+        page, err := pagedLister.ListPage(request, options)
+        if err != nil {
+            writeError(response, request.Request, err)
+            return
+        }
+        writePageLinks(request, response, options, page)
+        items = page.Items
+    } else if items, err = resourceHandler.resourceHandler.List(request); err != nil {
+        writeError(response, request.Request, err)
         return
     }
 
-    writeAnswer(response, http.StatusOK, itemsJson)
+    writeItems(request, response, http.StatusOK, items)
 }
 
 func (resourceHandler *resourceHandlerAdapter) handleRead(request *Request, response http.ResponseWriter) {
     item, err := resourceHandler.resourceHandler.Read(request)
     if err != nil {
-        writeError(response, err)
+        writeError(response, request.Request, err)
         return
     }
 
     if isNil(item) {
-        writeError(response, rest_error.NewByCode(http.StatusNotFound))
+        writeError(response, request.Request, rest_error.NewByCode(http.StatusNotFound))
         return
     }
 
-    itemJson, err := request.Marshal(item)
+    etag, err := resourceHandler.writeETag(request, response, item)
     if err != nil {
-        writeError(response, err)
+        writeError(response, request.Request, err)
+        return
+    }
+
+    if etag != "" && matchesETag(request.Header.Get("If-None-Match"), etag, true) {
+        writeAnswer(response, http.StatusNotModified, "", nil)
         return
     }
 
-    writeAnswer(response, http.StatusOK, itemJson)
+    writeValue(request, response, http.StatusOK, item)
 }
 
 func (resourceHandler *resourceHandlerAdapter) handleCreate(request *Request, response http.ResponseWriter) {
-    item, err := resourceHandler.readItem(request, Create)
+    itemContent, err := request.ReadBody()
     if err != nil {
-        writeError(response, err)
+        writeError(response, request.Request, err)
+        return
+    }
+
+    if key := request.Header.Get(IdempotencyKeyHeader); key != "" && request.server != nil && request.server.settings.idempotencyStore != nil {
+        resourceHandler.handleIdempotentCreate(request, response, key, itemContent)
+        return
+    }
+
+    resourceHandler.create(request, response, itemContent)
+}
+
+func (resourceHandler *resourceHandlerAdapter) create(request *Request, response http.ResponseWriter, itemContent []byte) {
+    if request.IsFlagSet(BatchFlag) {
+        batchCreator, ok := resourceHandler.extensions.(BatchCreator)
+        if !ok {
+            writeError(response, request.Request, rest_error.New(http.StatusNotImplemented, "Collection does not support batch create"))
+            return
+        }
+
+        elements := make([]json.RawMessage, 0)
+        if err := request.Unmarshal(itemContent, &elements); err != nil {
+            writeError(response, request.Request, err)
+            return
+        }
+
+        resourceHandler.handleBatchCreate(request, response, batchCreator, elements)
+        return
+    }
+
+    item, err := resourceHandler.decodeItem(request, itemContent, Create)
+    if err != nil {
+        writeError(response, request.Request, err)
         return
     }
 
     id, err := resourceHandler.resourceHandler.Create(request, item)
     if err != nil {
-        writeError(response, err)
+        writeError(response, request.Request, err)
         return
     }
 
-    relativeLocation := path.Clean(fmt.Sprintf("/%s/%s", strings.Trim(request.URL.Path, "/"), id))
+    response.Header().Add("Location", itemLocation(request, id))
 
-    response.Header().Add("Location", relativeLocation)
+    writeAnswer(response, http.StatusCreated, "", nil)
+}
 
-    writeAnswer(response, http.StatusCreated, nil)
+func itemLocation(request *Request, id string) string {
+    return path.Clean(fmt.Sprintf("/%s/%s", strings.Trim(request.URL.Path, "/"), id))
 }
 
 func (resourceHandler *resourceHandlerAdapter) handleUpdate(request *Request, response http.ResponseWriter) {
+    expectedVersion, err := resourceHandler.checkIfMatch(request)
+    if err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
     item, err := resourceHandler.readItem(request, Update)
     if err != nil {
-        writeError(response, err)
+        writeError(response, request.Request, err)
         return
     }
 
-    if err := resourceHandler.resourceHandler.Update(request, item); err != nil {
-        writeError(response, err)
+    if err := resourceHandler.update(request, response, item, Update, expectedVersion); err != nil {
+        writeError(response, request.Request, err)
         return
     }
 
-    writeAnswer(response, http.StatusOK, nil)
+    writeAnswer(response, http.StatusOK, "", nil)
 }
 
 func (resourceHandler *resourceHandlerAdapter) handleReplace(request *Request, response http.ResponseWriter) {
+    expectedVersion, err := resourceHandler.checkIfMatch(request)
+    if err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
     item, err := resourceHandler.readItem(request, Replace)
     if err != nil {
-        writeError(response, err)
+        writeError(response, request.Request, err)
         return
     }
 
-    if err := resourceHandler.resourceHandler.Replace(request, item); err != nil {
-        writeError(response, err)
+    if err := resourceHandler.update(request, response, item, Replace, expectedVersion); err != nil {
+        writeError(response, request.Request, err)
         return
     }
 
-    writeAnswer(response, http.StatusOK, nil)
+    writeAnswer(response, http.StatusOK, "", nil)
 }
 
 func (resourceHandler *resourceHandlerAdapter) handleDelete(request *Request, response http.ResponseWriter) {
-    if err := resourceHandler.resourceHandler.Delete(request); err != nil {
-        writeError(response, err)
+    expectedVersion, err := resourceHandler.checkIfMatch(request)
+    if err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
+    if versionedDeleter, ok := resourceHandler.extensions.(VersionedDeleter); ok {
+        err = versionedDeleter.DeleteVersion(request, expectedVersion)
+    } else {
+        err = resourceHandler.resourceHandler.Delete(request)
+    }
+
+    if err != nil {
+        writeError(response, request.Request, err)
         return
     }
 
-    writeAnswer(response, http.StatusOK, nil)
+    writeAnswer(response, http.StatusOK, "", nil)
 }
 
 func (resourceHandler *resourceHandlerAdapter) handleBatchDelete(request *Request, response http.ResponseWriter) {
+    selector, err := parseDeleteSelector(request.URL.Query(), resourceHandler.listPolicy)
+    if err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
+    request.deleteSelector = selector
+
+    if selectiveDeleter, ok := resourceHandler.extensions.(SelectiveBatchDeleter); ok {
+        deleted, err := selectiveDeleter.DeleteWhere(request, selector)
+        if err != nil {
+            writeError(response, request.Request, err)
+            return
+        }
+
+        writeValue(request, response, http.StatusOK, &DeleteResult{Deleted: deleted})
+        return
+    }
+
+    // BatchDelete knows nothing about ids and filters and deletes the whole collection
+    if !selector.All || len(selector.IDs) != 0 || len(selector.Filters) != 0 {
+        writeError(response, request.Request, rest_error.NewWithReason(http.StatusNotImplemented, ReasonSelectorNotSupported, "Collection supports deleting all items only"))
+        return
+    }
+
     if err := resourceHandler.resourceHandler.BatchDelete(request); err != nil {
-        writeError(response, err)
+        writeError(response, request.Request, err)
         return
     }
 
-    writeAnswer(response, http.StatusOK, nil)
+    writeAnswer(response, http.StatusNoContent, "", nil)
 }
 
 func (resourceHandler *resourceHandlerAdapter) handleCustomAction(request *Request, handler ActionHandler, response http.ResponseWriter) {
     if err := handler.Do(request); err != nil {
-        writeError(response, err)
+        writeError(response, request.Request, err)
         return
     }
 
-    writeAnswer(response, http.StatusOK, nil)
+    writeAnswer(response, http.StatusOK, "", nil)
 }
 
 func isNil(item interface{}) bool {
@@ -269,48 +403,98 @@ func (r *Request) Marshal(v interface{}) ([]byte, error) {
 }
 
 func (r *Request) GetMarshalFunc() (string, func(interface{}) ([]byte, error)) {
-    if r.IsFlagSet("pretty") {
-        return "    ", func(v interface{}) ([]byte, error) {
-            return json.MarshalIndent(v, "", "    ")
+    indent := r.indent()
+    _, codec, err := r.ResponseCodec()
+
+    return indent, func(v interface{}) ([]byte, error) {
+        if err != nil {
+            return nil, err
         }
+        return codec.Marshal(v, indent)
+    }
+}
+
+func (r *Request) marshalAnswer(v interface{}) (string, []byte, error) {
+    contentType, codec, err := r.ResponseCodec()
+    if err != nil {
+        return "", nil, err
+    }
+
+    content, err := codec.Marshal(v, r.indent())
+    if err != nil {
+        return "", nil, err
+    }
+
+    return contentType, content, nil
+}
+
+func (r *Request) indent() string {
+    if r.IsFlagSet("pretty") {
+        return "    "
     }
 
     if indentParam := r.URL.Query().Get("indent"); indentParam != "" {
         if indentCount, err := strconv.Atoi(indentParam); err == nil && 0 < indentCount && indentCount <= 32 {
-            indent := strings.Repeat(" ", indentCount)
-            return indent, func(v interface{}) ([]byte, error) {
-                return json.MarshalIndent(v, "", indent)
-            }
+            return strings.Repeat(" ", indentCount)
         }
     }
 
-    return "", func(v interface{}) ([]byte, error) {
-        return json.Marshal(v)
-    }
+    return ""
 }
 
 func (resourceHandler *resourceHandlerAdapter) readItem(request *Request, action ItemAction) (interface{}, error) {
-    itemJson, err := ioutil.ReadAll(request.Body)
-    if err != nil {
+    item := resourceHandler.resourceHandler.EmptyItem()
+
+    if err := request.Decode(item); err != nil {
+        return nil, err
+    }
+
+    if err := CheckRestrictions(item, action); err != nil {
         return nil, err
     }
 
+    return item, nil
+}
+
+func (resourceHandler *resourceHandlerAdapter) decodeItem(request *Request, itemContent []byte, action ItemAction) (interface{}, error) {
     item := resourceHandler.resourceHandler.EmptyItem()
 
-    if err := json.Unmarshal(itemJson, item); err != nil {
-        return nil, rest_error.New(http.StatusBadRequest, err.Error())
+    if err := request.Unmarshal(itemContent, item); err != nil {
+        return nil, err
     }
 
     if err := CheckRestrictions(item, action); err != nil {
-        return nil, rest_error.New(http.StatusBadRequest, err.Error())
+        return nil, err
     }
 
     return item, nil
 }
 
-func writeAnswer(response http.ResponseWriter, status int, content []byte) {
+func writeValue(request *Request, response http.ResponseWriter, status int, v interface{}) {
+    if strings.EqualFold(request.Method, "HEAD") {
+        contentType, _, err := request.ResponseCodec()
+        if err != nil {
+            writeError(response, request.Request, err)
+            return
+        }
+
+        response.Header().Set("Content-Type", contentType)
+        response.WriteHeader(status)
+        return
+    }
+
+    contentType, content, err := request.marshalAnswer(v)
+    if err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
+    writeAnswer(response, status, contentType, content)
+}
+
+func writeAnswer(response http.ResponseWriter, status int, contentType string, content []byte) {
     if content != nil {
-        response.Header().Add("Content-Type", "application/json")
+        response.Header().Add("Content-Type", contentType)
     }
 
     response.WriteHeader(status)
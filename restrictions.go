@@ -2,7 +2,10 @@ package rest
 
 import (
     "fmt"
+    "github.com/maxmanuylov/go-rest/error"
+    "net/http"
     "reflect"
+    "sort"
     "strings"
 )
 
@@ -13,110 +16,160 @@ const (
     requiredPrefix = "required@"
 )
 
-type problem int
+type Problem string
 
 const (
-    itemNotSpecified       problem = iota
-    severalItemsSpecified
-    readOnlyValueSpecified
-    emptyArray
+    ProblemNotSpecified     Problem = "required"
+    ProblemSeveralSpecified Problem = "oneOf"
+    ProblemReadOnly         Problem = "readonly"
+    ProblemEmptyArray       Problem = "nonempty"
 )
 
-func CheckRestrictions(item interface{}, action ItemAction) error {
-    if fields := getProblemFields(reflect.ValueOf(item), action, false); fields != nil {
-        if len(fields.paths) == 1 {
-            if fields.problem == emptyArray {
-                return fmt.Errorf("Array is empty: %s", fields.paths[0])
-            } else if fields.problem == readOnlyValueSpecified {
-                return fmt.Errorf("Value is read-only: %s", fields.paths[0])
-            } else { // itemNotSpecified
-                return fmt.Errorf("Field is not specified: %s", fields.paths[0])
-            }
-        } else if fields.problem == severalItemsSpecified {
-            return fmt.Errorf("Only one of the following fields can be specified:\n%s", strings.Join(fields.paths, "\n"))
-        } else { // itemNotSpecified
-            return fmt.Errorf("One of the following fields must be specified:\n%s", strings.Join(fields.paths, "\n"))
+type Violation struct {
+    Path         string
+    Problem      Problem
+    Alternatives []string
+}
+
+type ValidationError struct {
+    Violations []*Violation
+}
+
+func (err *ValidationError) Error() string {
+    messages := make([]string, 0, len(err.Violations))
+    for _, violation := range err.Violations {
+        if message := violation.message(); !contains(messages, message) {
+            messages = append(messages, message)
         }
     }
+    return strings.Join(messages, "\n")
+}
+
+func (err *ValidationError) RestError() *rest_error.Error {
+    fields := make([]*rest_error.FieldError, len(err.Violations))
+    for i, violation := range err.Violations {
+        fields[i] = &rest_error.FieldError{
+            Path:    violation.Path,
+            Reason:  string(violation.Problem),
+            Message: violation.message(),
+        }
+    }
+    return rest_error.NewWithReason(http.StatusBadRequest, rest_error.ReasonValidation, err.Error()).WithFields(fields...)
+}
+
+func (violation *Violation) message() string {
+    switch violation.Problem {
+    case ProblemEmptyArray:
+        return fmt.Sprintf("Array is empty: %s", violation.Path)
+    case ProblemReadOnly:
+        return fmt.Sprintf("Value is read-only: %s", violation.Path)
+    case ProblemSeveralSpecified:
+        return fmt.Sprintf("Only one of the following fields can be specified: %s", strings.Join(violation.Alternatives, ", "))
+    default:
+        if len(violation.Alternatives) > 1 {
+            return fmt.Sprintf("One of the following fields must be specified: %s", strings.Join(violation.Alternatives, ", "))
+        }
+        return fmt.Sprintf("Field is not specified: %s", violation.Path)
+    }
+}
+
+func CheckRestrictions(item interface{}, action ItemAction) error {
+    if violations := collectViolations(reflect.ValueOf(item), action, false, ""); len(violations) != 0 {
+        return &ValidationError{Violations: violations}
+    }
     return nil
 }
 
-func getProblemFields(value reflect.Value, action ItemAction, checkArrayIsNotEmpty bool) *problemFields {
+func collectViolations(value reflect.Value, action ItemAction, checkArrayIsNotEmpty bool, path string) []*Violation {
+    var violations []*Violation
+
     switch value.Kind() {
-    case reflect.Ptr:
-        return getProblemFields(value.Elem(), action, checkArrayIsNotEmpty)
+    case reflect.Ptr, reflect.Interface:
+        if !value.IsNil() {
+            return collectViolations(value.Elem(), action, checkArrayIsNotEmpty, path)
+        }
 
     case reflect.Array, reflect.Slice:
         if checkArrayIsNotEmpty && value.Len() == 0 {
-            return &problemFields{
-                paths: []string{""},
-                problem: emptyArray,
-            }
+            return []*Violation{{
+                Path:    path,
+                Problem: ProblemEmptyArray,
+            }}
         }
         for i := 0; i < value.Len(); i++ {
-            if fields := getProblemFields(value.Index(i), action, checkArrayIsNotEmpty); fields != nil {
-                return fields.withPrefix(fmt.Sprintf("[%d]", i))
-            }
+            violations = append(violations, collectViolations(value.Index(i), action, false, fmt.Sprintf("%s[%d]", path, i))...)
         }
 
     case reflect.Map:
-        for _, key := range value.MapKeys() {
-            if fields := getProblemFields(value.MapIndex(key), action, false); fields != nil {
-                return fields.withPrefix(fmt.Sprintf("[%v]", key))
-            }
+        for _, key := range sortedMapKeys(value) {
+            violations = append(violations, collectViolations(value.MapIndex(key), action, false, fmt.Sprintf("%s[%v]", path, key))...)
         }
 
     case reflect.Struct:
         valueType := value.Type()
         oneOfIndex := make(map[string]*oneOfData)
+        oneOfKeys := make([]string, 0)
 
         for i := 0; i < value.NumField(); i++ {
             field := value.Field(i)
             fieldType := valueType.Field(i)
+            if fieldType.PkgPath != "" && !fieldType.Anonymous {
+                continue
+            }
+
             r := getRestrictions(fieldType, action)
+            fieldPath := fmt.Sprintf("%s.%s", path, getFieldName(fieldType))
+
+            for _, oneOfKey := range r.oneOfKeys {
+                if _, ok := oneOfIndex[oneOfKey]; !ok {
+                    oneOfKeys = append(oneOfKeys, oneOfKey)
+                }
+            }
 
             if field.Kind() != reflect.Struct && isZero(field) {
                 if len(r.oneOfKeys) != 0 {
-                    fieldName := getFieldName(fieldType)
                     for _, oneOfKey := range r.oneOfKeys {
-                        getOrCreateOneOf(oneOfIndex, oneOfKey).addZeroField(fieldName, r.required)
+                        getOrCreateOneOf(oneOfIndex, oneOfKey).addZeroField(fieldPath, r.required)
                     }
                 } else if r.required {
-                    return &problemFields{
-                        paths: []string{fmt.Sprintf(".%s", getFieldName(fieldType))},
-                        problem: itemNotSpecified,
-                    }
+                    violations = append(violations, &Violation{
+                        Path:    fieldPath,
+                        Problem: ProblemNotSpecified,
+                    })
                 }
             } else {
                 if r.readOnly {
-                    return &problemFields{
-                        paths: []string{fmt.Sprintf(".%s", getFieldName(fieldType))},
-                        problem: readOnlyValueSpecified,
-                    }
+                    violations = append(violations, &Violation{
+                        Path:    fieldPath,
+                        Problem: ProblemReadOnly,
+                    })
+                    continue
                 }
-                if len(r.oneOfKeys) != 0 {
-                    fieldName := getFieldName(fieldType)
-                    for _, oneOfKey := range r.oneOfKeys {
-                        getOrCreateOneOf(oneOfIndex, oneOfKey).addSpecifiedField(fieldName, r.required)
-                    }
+                for _, oneOfKey := range r.oneOfKeys {
+                    getOrCreateOneOf(oneOfIndex, oneOfKey).addSpecifiedField(fieldPath, r.required)
                 }
-                if fields := getProblemFields(field, action, r.nonEmptyArray); fields != nil {
-                    if fieldType.Anonymous {
-                        return fields
-                    }
-                    return fields.withPrefix(fmt.Sprintf(".%s", getFieldName(fieldType)))
+                if fieldType.Anonymous {
+                    violations = append(violations, collectViolations(field, action, r.nonEmptyArray, path)...)
+                } else {
+                    violations = append(violations, collectViolations(field, action, r.nonEmptyArray, fieldPath)...)
                 }
             }
         }
 
-        for _, data := range oneOfIndex {
-            if fields := data.checkProblems(); fields != nil {
-                return fields.withPrefix(".")
-            }
+        for _, oneOfKey := range oneOfKeys {
+            violations = append(violations, oneOfIndex[oneOfKey].violations()...)
         }
     }
 
-    return nil
+    return violations
+}
+
+func sortedMapKeys(value reflect.Value) []reflect.Value {
+    keys := value.MapKeys()
+    sort.Slice(keys, func(i, j int) bool {
+        return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j])
+    })
+    return keys
 }
 
 func getOrCreateOneOf(oneOfIndex map[string]*oneOfData, oneOfKey string) *oneOfData {
@@ -193,39 +246,44 @@ func getFieldName(fieldType reflect.StructField) string {
 /* *** */
 
 type oneOfData struct {
-    fieldNames []string
-    specified  int
-    required   bool
+    fieldPaths     []string
+    specifiedPaths []string
+    required       bool
 }
 
-func (data *oneOfData) addSpecifiedField(fieldName string, required bool) {
-    data.addZeroField(fieldName, required)
-    data.specified++
+func (data *oneOfData) addSpecifiedField(fieldPath string, required bool) {
+    data.addZeroField(fieldPath, required)
+    data.specifiedPaths = append(data.specifiedPaths, fieldPath)
 }
 
-func (data *oneOfData) addZeroField(fieldName string, required bool) {
-    data.fieldNames = append(data.fieldNames, fieldName)
+func (data *oneOfData) addZeroField(fieldPath string, required bool) {
+    data.fieldPaths = append(data.fieldPaths, fieldPath)
     if required {
         data.required = true
     }
 }
 
-func (data *oneOfData) checkProblems() *problemFields {
-    if data.specified == 0 {
+func (data *oneOfData) violations() []*Violation {
+    if len(data.specifiedPaths) == 0 {
         if data.required {
-            return data.toProblem(itemNotSpecified)
+            return data.toViolations(data.fieldPaths, ProblemNotSpecified)
         }
-    } else if data.specified > 1 {
-        return data.toProblem(severalItemsSpecified)
+    } else if len(data.specifiedPaths) > 1 {
+        return data.toViolations(data.specifiedPaths, ProblemSeveralSpecified)
     }
     return nil
 }
 
-func (data *oneOfData) toProblem(problem problem) *problemFields {
-    return &problemFields{
-        paths: data.fieldNames,
-        problem: problem,
+func (data *oneOfData) toViolations(paths []string, problem Problem) []*Violation {
+    violations := make([]*Violation, len(paths))
+    for i, path := range paths {
+        violations[i] = &Violation{
+            Path:         path,
+            Problem:      problem,
+            Alternatives: data.fieldPaths,
+        }
     }
+    return violations
 }
 
 /* *** */
@@ -236,23 +294,3 @@ type restrictions struct {
     required      bool
     oneOfKeys     []string
 }
-
-/* *** */
-
-type problemFields struct {
-    paths   []string
-    problem problem
-}
-
-func (fields *problemFields) withPrefix(prefix string) *problemFields {
-    newFields := &problemFields{
-        paths: make([]string, len(fields.paths)),
-        problem: fields.problem,
-    }
-
-    for i, path := range fields.paths {
-        newFields.paths[i] = fmt.Sprintf("%s%s", prefix, path)
-    }
-
-    return newFields
-}
@@ -0,0 +1,83 @@
+package rest
+
+import (
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "sort"
+)
+
+type Route struct {
+    Path        string   `json:"path"`
+    Methods     []string `json:"methods"`
+    Actions     []string `json:"actions,omitempty"`
+    HandlerType string   `json:"handlerType,omitempty"`
+}
+
+func (server *Server) Routes() []*Route {
+    routes := make([]*Route, 0)
+
+    server.walkCollections(func(collection *Collection, collectionPath, itemPath string, _ []*Collection) {
+        if collection.handler == nil {
+            return
+        }
+
+        if adapter, ok := collection.handler.(*resourceHandlerAdapter); ok {
+            handlerType := fmt.Sprintf("%T", adapter.extensions)
+            routes = append(routes, &Route{
+                Path:        collectionPath,
+                Methods:     adapter.allowedMethods(true),
+                HandlerType: handlerType,
+            }, &Route{
+                Path:        itemPath,
+                Methods:     adapter.allowedMethods(false),
+                Actions:     adapter.actionMethods(),
+                HandlerType: handlerType,
+            })
+            return
+        }
+
+        handlerType := fmt.Sprintf("%T", collection.handler)
+        routes = append(routes, &Route{
+            Path:        collectionPath,
+            HandlerType: handlerType,
+        }, &Route{
+            Path:        itemPath,
+            HandlerType: handlerType,
+        })
+    })
+
+    return routes
+}
+
+func (server *Server) ServeRoutes(pattern string) {
+    server.mux.HandleFunc(server.path(pattern), func(response http.ResponseWriter, request *http.Request) {
+        content, err := json.MarshalIndent(server.Routes(), "", "  ")
+        if err != nil {
+            writeError(response, request, err)
+            return
+        }
+        writeAnswer(response, http.StatusOK, JsonContentType, content)
+    })
+}
+
+func (resourceHandler *resourceHandlerAdapter) allowedMethods(collectionRequest bool) []string {
+    if collectionRequest {
+        methods := []string{"GET", "HEAD", "POST", "DELETE", "OPTIONS"}
+        if _, ok := resourceHandler.extensions.(BatchUpdater); ok {
+            methods = append(methods, "PATCH")
+        }
+        return methods
+    }
+
+    return append([]string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}, resourceHandler.actionMethods()...)
+}
+
+func (resourceHandler *resourceHandlerAdapter) actionMethods() []string {
+    methods := make([]string, 0, len(resourceHandler.customActions))
+    for method := range resourceHandler.customActions {
+        methods = append(methods, method)
+    }
+    sort.Strings(methods)
+    return methods
+}
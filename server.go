@@ -1,23 +1,81 @@
 package rest
 
 import (
+    "context"
     "crypto/tls"
     "fmt"
-    "github.com/maxmanuylov/utils/application"
+    "log"
     "net"
     "net/http"
+    "os"
+    "os/signal"
     "strings"
+    "syscall"
     "time"
 )
 
 type Server struct {
-    mux    *http.ServeMux
-    prefix string
+    mux           *http.ServeMux
+    prefix        string
+    settings      *serverSettings
+    parent        *Server
+    middlewares   []Middleware
+    corsPolicy    *CORSPolicy
+    authenticator Authenticator
+    maxBodySize   int64
 }
 
+type serverSettings struct {
+    codecs           *codecRegistry
+    logger           *log.Logger
+    panicHandlers    []PanicHandler
+    httpServer       *http.Server
+    shutdownTimeout  time.Duration
+    collections      []*Collection
+    strictDecoding   bool
+    idempotencyStore IdempotencyStore
+}
+
+type Timeouts struct {
+    Read       time.Duration
+    ReadHeader time.Duration
+    Write      time.Duration
+    Idle       time.Duration
+    Shutdown   time.Duration
+}
+
+const (
+    DefaultShutdownTimeout = 30 * time.Second
+)
+
 func NewServer() *Server {
     mux := http.NewServeMux()
-    return &Server{mux: mux}
+    return &Server{
+        mux: mux,
+        settings: &serverSettings{
+            codecs:          newCodecRegistry(),
+            httpServer:      &http.Server{Handler: mux},
+            shutdownTimeout: DefaultShutdownTimeout,
+        },
+    }
+}
+
+func (server *Server) SetTimeouts(timeouts Timeouts) *Server {
+    httpServer := server.settings.httpServer
+    httpServer.ReadTimeout = timeouts.Read
+    httpServer.ReadHeaderTimeout = timeouts.ReadHeader
+    httpServer.WriteTimeout = timeouts.Write
+    httpServer.IdleTimeout = timeouts.Idle
+
+    if timeouts.Shutdown > 0 {
+        server.settings.shutdownTimeout = timeouts.Shutdown
+    }
+
+    return server
+}
+
+func (server *Server) HttpServer() *http.Server {
+    return server.settings.httpServer
 }
 
 func (server *Server) CustomHandler(pattern string, handler http.Handler) {
@@ -31,10 +89,24 @@ func (server *Server) CustomHandlerFunc(pattern string, handler func(http.Respon
 func (server *Server) WithPrefix(prefix string) *Server {
     return &Server{
         mux: server.mux,
+        settings: server.settings,
+        parent: server,
         prefix: server.path(fmt.Sprintf("/%s", strings.TrimPrefix(strings.TrimSuffix(prefix, "/"), "/"))),
     }
 }
 
+func (server *Server) Use(middlewares... Middleware) *Server {
+    server.middlewares = append(server.middlewares, middlewares...)
+    return server
+}
+
+func (server *Server) chain(handler Handler) Handler {
+    for current := server; current != nil; current = current.parent {
+        handler = wrap(handler, current.middlewares)
+    }
+    return handler
+}
+
 func (server *Server) path(path string) string {
     return fmt.Sprintf("%s%s", server.prefix, path)
 }
@@ -55,9 +127,51 @@ func (server *Server) ListenTLS(addr *net.TCPAddr, config *tls.Config) (net.List
     return tls.NewListener(innerListener, config), nil
 }
 
-func (server *Server) Serve(listener net.Listener) {
-    httpServer := &http.Server{Handler: server.mux}
-    httpServer.Serve(listener)
+func (server *Server) Serve(listener net.Listener) error {
+    return server.settings.httpServer.Serve(listener)
+}
+
+// Shutdown stops the underlying http.Server for good: a server that has been shut down cannot serve again.
+func (server *Server) Shutdown(ctx context.Context) error {
+    return server.settings.httpServer.Shutdown(ctx)
+}
+
+// Run serves the listeners until ctx is done or one of them fails, then shuts the server down gracefully.
+// The server runs only once, see Shutdown.
+func (server *Server) Run(ctx context.Context, listeners... net.Listener) error {
+    serveErrors := make(chan error, len(listeners))
+
+    for _, listener := range listeners {
+        go func(listener net.Listener) {
+            serveErrors <- server.Serve(listener)
+        }(listener)
+    }
+
+    var runErr error
+    pending := len(listeners)
+
+    select {
+    case <-ctx.Done():
+    case runErr = <-serveErrors:
+        pending--
+    }
+
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), server.settings.shutdownTimeout)
+    defer cancel()
+
+    shutdownErr := server.Shutdown(shutdownCtx)
+
+    for ; pending > 0; pending-- {
+        if err := <-serveErrors; runErr == nil || runErr == http.ErrServerClosed {
+            runErr = err
+        }
+    }
+
+    if runErr != nil && runErr != http.ErrServerClosed {
+        return runErr
+    }
+
+    return shutdownErr
 }
 
 type tcpKeepAliveListener struct {
@@ -83,11 +197,10 @@ func (server *Server) ListenAndServe(addr *net.TCPAddr) error {
     }
     defer listener.Close()
 
-    go server.Serve(listener)
-
-    application.WaitForTermination()
+    ctx, cancel := terminationContext()
+    defer cancel()
 
-    return nil
+    return server.Run(ctx, listener)
 }
 
 func (server *Server) ListenAndServeTLS(addr *net.TCPAddr, config *tls.Config) error {
@@ -97,11 +210,10 @@ func (server *Server) ListenAndServeTLS(addr *net.TCPAddr, config *tls.Config) e
     }
     defer listener.Close()
 
-    go server.Serve(listener)
+    ctx, cancel := terminationContext()
+    defer cancel()
 
-    application.WaitForTermination()
-
-    return nil
+    return server.Run(ctx, listener)
 }
 
 func (server *Server) ListenAndServeFull(addr *net.TCPAddr, tlsAddr *net.TCPAddr, config *tls.Config) error {
@@ -117,10 +229,26 @@ func (server *Server) ListenAndServeFull(addr *net.TCPAddr, tlsAddr *net.TCPAddr
     }
     defer tlsListener.Close()
 
-    go server.Serve(listener)
-    go server.Serve(tlsListener)
+    ctx, cancel := terminationContext()
+    defer cancel()
+
+    return server.Run(ctx, listener, tlsListener)
+}
+
+func terminationContext() (context.Context, context.CancelFunc) {
+    ctx, cancel := context.WithCancel(context.Background())
+
+    signals := make(chan os.Signal, 1)
+    signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
 
-    application.WaitForTermination()
+    go func() {
+        defer signal.Stop(signals)
+        select {
+        case <-signals:
+            cancel()
+        case <-ctx.Done():
+        }
+    }()
 
-    return nil
+    return ctx, cancel
 }
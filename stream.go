@@ -0,0 +1,297 @@
+package rest
+
+import (
+    "bufio"
+    "encoding"
+    "encoding/json"
+    "github.com/maxmanuylov/go-rest/error"
+    "net/http"
+    "reflect"
+    "strings"
+)
+
+// writeItems writes a JSON list element by element instead of marshaling it as a whole;
+// other codecs, indented output and non-slice values go through writeValue. An element failing to encode
+// after the status has been sent leaves the array unterminated and is reported in the X-Stream-Error trailer.
+func writeItems(request *Request, response http.ResponseWriter, status int, items interface{}) {
+    value := reflect.ValueOf(items)
+    for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+        if value.IsNil() {
+            break
+        }
+        value = value.Elem()
+    }
+
+    if !streamable(items, value) || strings.EqualFold(request.Method, "HEAD") || request.indent() != "" {
+        writeValue(request, response, status, items)
+        return
+    }
+
+    contentType, codec, err := request.ResponseCodec()
+    if err != nil {
+        writeError(response, request.Request, err)
+        return
+    }
+
+    if _, ok := codec.(JsonCodec); !ok {
+        writeValue(request, response, status, items)
+        return
+    }
+
+    if value.Kind() == reflect.Array && !value.CanAddr() {
+        addressable := reflect.New(value.Type()).Elem()
+        addressable.Set(value)
+        value = addressable
+    }
+
+    var first []byte
+    if value.Len() != 0 {
+        if first, err = json.Marshal(addressableItem(value.Index(0))); err != nil {
+            writeError(response, request.Request, err)
+            return
+        }
+    }
+
+    response.Header().Set("Content-Type", contentType)
+    response.Header().Set("Trailer", StreamErrorTrailer)
+    response.WriteHeader(status)
+
+    if _, err := response.Write([]byte{'['}); err != nil {
+        return
+    }
+
+    for i := 0; i < value.Len(); i++ {
+        element := first
+        if i != 0 {
+            if element, err = json.Marshal(addressableItem(value.Index(i))); err != nil {
+                request.logf("rest: failed to encode list item %d of %s: %v", i, request.URL.Path, err)
+                setStreamError(response, err)
+                return
+            }
+            element = append([]byte{','}, element...)
+        }
+        if _, err := response.Write(element); err != nil {
+            return
+        }
+    }
+
+    response.Write([]byte{']'})
+}
+
+// addressableItem lets json.Marshal use pointer receiver MarshalJSON methods of list elements,
+// as it does when marshaling the whole slice
+func addressableItem(value reflect.Value) interface{} {
+    if !value.IsValid() {
+        return nil
+    }
+    if !value.CanAddr() {
+        if value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+            return value.Interface()
+        }
+        addressable := reflect.New(value.Type()).Elem()
+        addressable.Set(value)
+        value = addressable
+    }
+    return value.Addr().Interface()
+}
+
+func streamable(items interface{}, value reflect.Value) bool {
+    if value.Kind() != reflect.Slice && value.Kind() != reflect.Array || value.Kind() == reflect.Slice && value.IsNil() {
+        return false
+    }
+
+    if value.Type().Elem().Kind() == reflect.Uint8 {
+        return false
+    }
+
+    for _, v := range []interface{}{items, value.Interface()} {
+        if _, ok := v.(json.Marshaler); ok {
+            return false
+        }
+        if _, ok := v.(encoding.TextMarshaler); ok {
+            return false
+        }
+    }
+
+    return true
+}
+
+const (
+    NdjsonContentType  = "application/x-ndjson"
+    StreamErrorTrailer = "X-Stream-Error"
+
+    streamFlushInterval = 100
+)
+
+// StreamingLister yields list items one by one instead of returning them all at once. A non-nil error from yield
+// means the client is gone and listing should stop. An error returned after the first item has been sent
+// cannot change the status any more: the JSON array is left unterminated and the error envelope is sent
+// in the X-Stream-Error trailer.
+type StreamingLister interface {
+    ListStream(request *Request, options *ListOptions, yield func(item interface{}) error) error
+}
+
+func (resourceHandler *resourceHandlerAdapter) streamingLister(request *Request) (StreamingLister, bool) {
+    streamingLister, ok := resourceHandler.extensions.(StreamingLister)
+    if !ok {
+        return nil, false
+    }
+
+    if _, paged := resourceHandler.extensions.(PagedLister); paged {
+        query := request.URL.Query()
+        if query.Get(LimitParam) != "" || query.Get(OffsetParam) != "" || query.Get(CursorParam) != "" {
+            return nil, false
+        }
+    }
+
+    return streamingLister, true
+}
+
+func (resourceHandler *resourceHandlerAdapter) handleStreamingList(request *Request, response http.ResponseWriter, streamingLister StreamingLister, options *ListOptions) {
+    ndjson := false
+    if acceptedRanges := rest_error.AcceptedRanges(request.Header.Get("Accept")); len(acceptedRanges) != 0 && acceptedRanges[0] == NdjsonContentType {
+        ndjson = true
+    }
+
+    contentType := NdjsonContentType
+    if !ndjson {
+        var codec Codec
+        var err error
+        if contentType, codec, err = request.ResponseCodec(); err != nil {
+            writeError(response, request.Request, err)
+            return
+        }
+
+        if _, ok := codec.(JsonCodec); !ok {
+            items := make([]interface{}, 0)
+            if err := streamingLister.ListStream(request, options, func(item interface{}) error {
+                items = append(items, item)
+                return nil
+            }); err != nil {
+                writeError(response, request.Request, err)
+                return
+            }
+            writeValue(request, response, http.StatusOK, items)
+            return
+        }
+    }
+
+    if strings.EqualFold(request.Method, "HEAD") {
+        response.Header().Set("Content-Type", contentType)
+        response.WriteHeader(http.StatusOK)
+        return
+    }
+
+    stream := &itemStream{
+        response:    response,
+        writer:      bufio.NewWriter(response),
+        contentType: contentType,
+        ndjson:      ndjson,
+    }
+
+    if err := streamingLister.ListStream(request, options, stream.yield); err != nil {
+        if !stream.started {
+            writeError(response, request.Request, err)
+            return
+        }
+        request.logf("rest: list stream of %s failed after %d items: %v", request.URL.Path, stream.count, err)
+        stream.fail(err)
+        return
+    }
+
+    stream.finish()
+}
+
+type itemStream struct {
+    response    http.ResponseWriter
+    writer      *bufio.Writer
+    contentType string
+    ndjson      bool
+    started     bool
+    count       int
+    err         error
+}
+
+func (stream *itemStream) start() {
+    stream.started = true
+
+    header := stream.response.Header()
+    header.Set("Content-Type", stream.contentType)
+    header.Set("Trailer", StreamErrorTrailer)
+
+    stream.response.WriteHeader(http.StatusOK)
+
+    if !stream.ndjson {
+        stream.write([]byte{'['})
+    }
+}
+
+func (stream *itemStream) yield(item interface{}) error {
+    if stream.err != nil {
+        return stream.err
+    }
+
+    content, err := json.Marshal(addressableItem(reflect.ValueOf(item)))
+    if err != nil {
+        return err
+    }
+
+    if !stream.started {
+        stream.start()
+    }
+
+    if stream.ndjson {
+        content = append(content, '\n')
+    } else if stream.count != 0 {
+        stream.write([]byte{','})
+    }
+
+    stream.write(content)
+    stream.count++
+
+    if stream.count % streamFlushInterval == 0 {
+        stream.flush()
+    }
+
+    return stream.err
+}
+
+func (stream *itemStream) write(content []byte) {
+    if stream.err == nil {
+        _, stream.err = stream.writer.Write(content)
+    }
+}
+
+func (stream *itemStream) flush() {
+    if stream.err == nil {
+        stream.err = stream.writer.Flush()
+    }
+    if flusher, ok := stream.response.(http.Flusher); ok && stream.err == nil {
+        flusher.Flush()
+    }
+}
+
+func (stream *itemStream) finish() {
+    if !stream.started {
+        stream.start()
+    }
+    if !stream.ndjson {
+        stream.write([]byte{']'})
+    }
+    stream.flush()
+}
+
+func (stream *itemStream) fail(err error) {
+    stream.flush()
+    setStreamError(stream.response, err)
+}
+
+func setStreamError(response http.ResponseWriter, err error) {
+    envelope := struct {
+        Error *rest_error.Error `json:"error"`
+    }{rest_error.From(err)}
+
+    if content, err := json.Marshal(envelope); err == nil {
+        response.Header().Set(StreamErrorTrailer, string(content))
+    }
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+type money struct {
+    Cents int
+}
+
+func (m *money) MarshalJSON() ([]byte, error) {
+    return []byte(fmt.Sprintf(`"%d.%02d"`, m.Cents / 100, m.Cents % 100)), nil
+}
+
+type moneyList struct {
+    batchDeleteItems
+
+    items []money
+}
+
+func (h *moneyList) List(*Request) (interface{}, error) {
+    return h.items, nil
+}
+
+type moneyStream struct {
+    moneyList
+}
+
+func (h *moneyStream) ListStream(request *Request, options *ListOptions, yield func(item interface{}) error) error {
+    for _, item := range h.items {
+        if err := yield(item); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+func listWithAccept(server *Server, path, accept string) *httptest.ResponseRecorder {
+    request := httptest.NewRequest("GET", path, nil)
+    request.Header.Set("Accept", accept)
+    response := httptest.NewRecorder()
+    server.mux.ServeHTTP(response, request)
+    return response
+}
+
+func TestStreamedItemsMatchWholeSliceEncoding(t *testing.T) {
+    items := []money{{Cents: 150}, {Cents: 275}}
+
+    expected, err := json.Marshal(items)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    elements := make([]json.RawMessage, 0)
+    if err := json.Unmarshal(expected, &elements); err != nil {
+        t.Fatal(err)
+    }
+
+    expectedNdjson := make([]byte, 0)
+    for _, element := range elements {
+        expectedNdjson = append(append(expectedNdjson, element...), '\n')
+    }
+
+    server := NewServer()
+    server.Collection("list").Handler(&moneyList{items: items})
+    server.Collection("stream").Handler(&moneyStream{moneyList{items: items}})
+
+    tests := []struct {
+        path     string
+        accept   string
+        expected []byte
+    }{
+        {path: "/list", accept: JsonContentType, expected: expected},
+        {path: "/stream", accept: JsonContentType, expected: expected},
+        {path: "/stream", accept: NdjsonContentType, expected: expectedNdjson},
+    }
+
+    for _, test := range tests {
+        response := listWithAccept(server, test.path, test.accept)
+        if response.Code != http.StatusOK {
+            t.Errorf("%s %s: unexpected status %d", test.path, test.accept, response.Code)
+        }
+        if !bytes.Equal(response.Body.Bytes(), test.expected) {
+            t.Errorf("%s %s: expected %s, got %s", test.path, test.accept, test.expected, response.Body.Bytes())
+        }
+    }
+}
+
+func TestStreamedArrayItemsUsePointerMarshalers(t *testing.T) {
+    items := [2]money{{Cents: 150}, {Cents: 275}}
+
+    request := &Request{Request: httptest.NewRequest("GET", "/", nil)}
+    response := httptest.NewRecorder()
+    writeItems(request, response, http.StatusOK, items)
+
+    if expected := `["1.50","2.75"]`; response.Body.String() != expected {
+        t.Errorf("expected %s, got %s", expected, response.Body.String())
+    }
+}
@@ -0,0 +1,66 @@
+package rest
+
+type TypedResourceHandler[T any] interface {
+    Create(request *Request, item *T) (string, error)
+    Read(request *Request) (*T, error)
+    List(request *Request) ([]*T, error)
+    Update(request *Request, item *T) error
+    Replace(request *Request, item *T) error
+    Delete(request *Request) error
+    BatchDelete(request *Request) error
+}
+
+func TypedHandler[T any](collection *Collection, handler TypedResourceHandler[T]) *ResourceCollection {
+    resourceCollection := collection.Handler(&typedResourceHandler[T]{handler})
+    resourceCollection.resourceHandler.extensions = handler
+    return resourceCollection
+}
+
+/* *** */
+
+type typedResourceHandler[T any] struct {
+    handler TypedResourceHandler[T]
+}
+
+func (h *typedResourceHandler[T]) EmptyItem() interface{} {
+    return new(T)
+}
+
+func (h *typedResourceHandler[T]) Create(request *Request, item interface{}) (string, error) {
+    return h.handler.Create(request, item.(*T))
+}
+
+func (h *typedResourceHandler[T]) Read(request *Request) (interface{}, error) {
+    item, err := h.handler.Read(request)
+    if err != nil || item == nil {
+        return nil, err
+    }
+    return item, nil
+}
+
+func (h *typedResourceHandler[T]) List(request *Request) (interface{}, error) {
+    items, err := h.handler.List(request)
+    if err != nil {
+        return nil, err
+    }
+    if items == nil {
+        items = make([]*T, 0)
+    }
+    return items, nil
+}
+
+func (h *typedResourceHandler[T]) Update(request *Request, item interface{}) error {
+    return h.handler.Update(request, item.(*T))
+}
+
+func (h *typedResourceHandler[T]) Replace(request *Request, item interface{}) error {
+    return h.handler.Replace(request, item.(*T))
+}
+
+func (h *typedResourceHandler[T]) Delete(request *Request) error {
+    return h.handler.Delete(request)
+}
+
+func (h *typedResourceHandler[T]) BatchDelete(request *Request) error {
+    return h.handler.BatchDelete(request)
+}
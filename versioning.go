@@ -0,0 +1,215 @@
+package rest
+
+import (
+    "fmt"
+    "github.com/maxmanuylov/go-rest/error"
+    "net/http"
+    "strings"
+)
+
+const (
+    ReasonVersionConflict = "version_conflict"
+)
+
+var (
+    ErrPreconditionFailed = rest_error.NewByCode(http.StatusPreconditionFailed)
+    ErrVersionConflict    = rest_error.NewWithReason(http.StatusPreconditionFailed, ReasonVersionConflict, "Item was modified concurrently")
+)
+
+type Versioned interface {
+    Version() string
+}
+
+type VersionedResourceHandler interface {
+    Version(request *Request) (string, error)
+}
+
+// VersionedUpdater makes conditional writes atomic. expectedVersion is the current version the If-Match header
+// was checked against, or "" for unconditional requests; the handler must compare it with the stored version
+// as part of the write and return ErrVersionConflict if they differ. The returned version is sent as the ETag.
+type VersionedUpdater interface {
+    UpdateVersion(request *Request, item interface{}, expectedVersion string) (string, error)
+    ReplaceVersion(request *Request, item interface{}, expectedVersion string) (string, error)
+}
+
+// VersionedDeleter is the VersionedUpdater counterpart for Delete.
+type VersionedDeleter interface {
+    DeleteVersion(request *Request, expectedVersion string) error
+}
+
+func (resourceHandler *resourceHandlerAdapter) itemVersion(request *Request, item interface{}) (string, error) {
+    if versionedHandler, ok := resourceHandler.extensions.(VersionedResourceHandler); ok {
+        return versionedHandler.Version(request)
+    }
+    if versioned, ok := item.(Versioned); ok {
+        return versioned.Version(), nil
+    }
+    return "", nil
+}
+
+func (resourceHandler *resourceHandlerAdapter) versioned(item interface{}) bool {
+    if _, ok := resourceHandler.extensions.(VersionedResourceHandler); ok {
+        return true
+    }
+    _, ok := item.(Versioned)
+    return ok
+}
+
+func (resourceHandler *resourceHandlerAdapter) currentVersion(request *Request) (string, bool, error) {
+    if versionedHandler, ok := resourceHandler.extensions.(VersionedResourceHandler); ok {
+        version, err := versionedHandler.Version(request)
+        return version, version != "", err
+    }
+
+    item, err := resourceHandler.resourceHandler.Read(request)
+    if err != nil || isNil(item) {
+        return "", false, err
+    }
+
+    if versioned, ok := item.(Versioned); ok {
+        return versioned.Version(), true, nil
+    }
+
+    return "", true, nil
+}
+
+// IfMatch returns the versions listed in the If-Match header, with ETag quoting removed; "*" is returned as is.
+// Weak tags are rejected as If-Match requires the strong comparison.
+func (r *Request) IfMatch() ([]string, error) {
+    ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+    if ifMatch == "" {
+        return nil, nil
+    }
+
+    versions := make([]string, 0)
+    for _, candidate := range strings.Split(ifMatch, ",") {
+        candidate = strings.TrimSpace(candidate)
+        switch {
+        case candidate == "*":
+            versions = append(versions, candidate)
+        case strings.HasPrefix(candidate, "W/"):
+            return nil, rest_error.New(http.StatusBadRequest, "If-Match requires strong entity tags")
+        case len(candidate) >= 2 && strings.HasPrefix(candidate, "\"") && strings.HasSuffix(candidate, "\""):
+            versions = append(versions, candidate[1:len(candidate) - 1])
+        default:
+            return nil, rest_error.New(http.StatusBadRequest, fmt.Sprintf("Invalid entity tag in If-Match: %s", candidate))
+        }
+    }
+    return versions, nil
+}
+
+// MatchesVersion reports whether the If-Match header (if any) allows a write over the given current version.
+func (r *Request) MatchesVersion(version string) bool {
+    versions, err := r.IfMatch()
+    if err != nil {
+        return false
+    }
+    if versions == nil {
+        return true
+    }
+    for _, candidate := range versions {
+        if version != "" && (candidate == "*" || formatETag(candidate) == formatETag(version)) {
+            return true
+        }
+    }
+    return false
+}
+
+// checkIfMatch returns the current version the write is expected to find, "" for unconditional requests
+func (resourceHandler *resourceHandlerAdapter) checkIfMatch(request *Request) (string, error) {
+    versions, err := request.IfMatch()
+    if err != nil || versions == nil {
+        return "", err
+    }
+
+    version, exists, err := resourceHandler.currentVersion(request)
+    if err != nil {
+        return "", err
+    }
+
+    if !exists {
+        return "", rest_error.New(http.StatusPreconditionFailed, "Item does not exist")
+    }
+
+    if len(versions) == 1 && versions[0] == "*" {
+        return version, nil
+    }
+
+    if !request.MatchesVersion(version) {
+        return "", rest_error.New(http.StatusPreconditionFailed, "Item version does not match")
+    }
+
+    return version, nil
+}
+
+// update writes the item through VersionedUpdater when the handler supports it and sends the new ETag
+func (resourceHandler *resourceHandlerAdapter) update(request *Request, response http.ResponseWriter, item interface{}, action ItemAction, expectedVersion string) error {
+    version := ""
+
+    if versionedUpdater, ok := resourceHandler.extensions.(VersionedUpdater); ok {
+        var err error
+        if action == Replace {
+            version, err = versionedUpdater.ReplaceVersion(request, item, expectedVersion)
+        } else {
+            version, err = versionedUpdater.UpdateVersion(request, item, expectedVersion)
+        }
+        if err != nil {
+            return err
+        }
+    } else if action == Replace {
+        if err := resourceHandler.resourceHandler.Replace(request, item); err != nil {
+            return err
+        }
+    } else if err := resourceHandler.resourceHandler.Update(request, item); err != nil {
+        return err
+    }
+
+    if version == "" && resourceHandler.versioned(item) {
+        var err error
+        if version, _, err = resourceHandler.currentVersion(request); err != nil {
+            return err
+        }
+    }
+
+    if version != "" {
+        response.Header().Set("ETag", formatETag(version))
+    }
+
+    return nil
+}
+
+func (resourceHandler *resourceHandlerAdapter) writeETag(request *Request, response http.ResponseWriter, item interface{}) (string, error) {
+    version, err := resourceHandler.itemVersion(request, item)
+    if err != nil || version == "" {
+        return "", err
+    }
+
+    etag := formatETag(version)
+    response.Header().Set("ETag", etag)
+
+    return etag, nil
+}
+
+func formatETag(version string) string {
+    if strings.HasPrefix(version, "\"") || strings.HasPrefix(version, "W/\"") {
+        return version
+    }
+    return fmt.Sprintf("\"%s\"", version)
+}
+
+func matchesETag(header, etag string, weak bool) bool {
+    for _, candidate := range strings.Split(header, ",") {
+        candidate = strings.TrimSpace(candidate)
+        if candidate == "*" {
+            return true
+        }
+        if weak {
+            if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+                return true
+            }
+        } else if !strings.HasPrefix(candidate, "W/") && !strings.HasPrefix(etag, "W/") && candidate == etag {
+            return true
+        }
+    }
+    return false
+}
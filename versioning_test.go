@@ -0,0 +1,105 @@
+package rest
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "testing"
+)
+
+type versionedItem struct {
+    Name string `json:"name"`
+    Rev  int    `json:"rev"`
+}
+
+func (item *versionedItem) Version() string {
+    return strconv.Itoa(item.Rev)
+}
+
+type versionedItems struct {
+    stored *versionedItem
+}
+
+func (h *versionedItems) EmptyItem() interface{}                    { return &versionedItem{} }
+func (h *versionedItems) Create(*Request, interface{}) (string, error) { return "1", nil }
+func (h *versionedItems) List(*Request) (interface{}, error)         { return nil, nil }
+func (h *versionedItems) Delete(*Request) error                       { return nil }
+func (h *versionedItems) BatchDelete(*Request) error                  { return nil }
+
+func (h *versionedItems) Read(*Request) (interface{}, error) {
+    item := *h.stored
+    return &item, nil
+}
+
+func (h *versionedItems) Update(request *Request, item interface{}) error {
+    h.stored = &versionedItem{Name: item.(*versionedItem).Name, Rev: h.stored.Rev + 1}
+    return nil
+}
+
+func (h *versionedItems) Replace(request *Request, item interface{}) error {
+    return h.Update(request, item)
+}
+
+type atomicVersionedItems struct {
+    versionedItems
+}
+
+func (h *atomicVersionedItems) UpdateVersion(request *Request, item interface{}, expectedVersion string) (string, error) {
+    if expectedVersion != "" && expectedVersion != h.stored.Version() {
+        return "", ErrVersionConflict
+    }
+    h.Update(request, item)
+    return h.stored.Version(), nil
+}
+
+func (h *atomicVersionedItems) ReplaceVersion(request *Request, item interface{}, expectedVersion string) (string, error) {
+    return h.UpdateVersion(request, item, expectedVersion)
+}
+
+func sendWithIfMatch(server *Server, method, ifMatch string) *httptest.ResponseRecorder {
+    request := httptest.NewRequest(method, "/items/1", strings.NewReader(`{"name":"b"}`))
+    request.Header.Set("Content-Type", "application/json")
+    if ifMatch != "" {
+        request.Header.Set("If-Match", ifMatch)
+    }
+    response := httptest.NewRecorder()
+    server.mux.ServeHTTP(response, request)
+    return response
+}
+
+func TestIfMatchPassesExpectedVersion(t *testing.T) {
+    handler := &atomicVersionedItems{versionedItems{stored: &versionedItem{Name: "a", Rev: 1}}}
+
+    server := NewServer()
+    server.Collection("items").Handler(handler)
+
+    response := sendWithIfMatch(server, "PUT", `"1"`)
+    if response.Code != http.StatusOK || response.Header().Get("ETag") != `"2"` {
+        t.Fatalf("unexpected response %d with ETag %q", response.Code, response.Header().Get("ETag"))
+    }
+
+    // the second editor based the change on the same version
+    if response := sendWithIfMatch(server, "PUT", `"1"`); response.Code != http.StatusPreconditionFailed {
+        t.Errorf("expected 412 for a stale version, got %d", response.Code)
+    }
+
+    if response := sendWithIfMatch(server, "PUT", `W/"2"`); response.Code != http.StatusBadRequest {
+        t.Errorf("expected 400 for a weak tag, got %d", response.Code)
+    }
+
+    if handler.stored.Rev != 2 {
+        t.Errorf("expected a single write, got revision %d", handler.stored.Rev)
+    }
+}
+
+func TestItemVersionETagAfterWrite(t *testing.T) {
+    handler := &versionedItems{stored: &versionedItem{Name: "a", Rev: 1}}
+
+    server := NewServer()
+    server.Collection("items").Handler(handler)
+
+    if response := sendWithIfMatch(server, "PUT", ""); response.Header().Get("ETag") != `"2"` {
+        t.Errorf("expected ETag \"2\" after the write, got %q", response.Header().Get("ETag"))
+    }
+}